@@ -6,6 +6,7 @@ toolchain go1.26.3
 
 require (
 	github.com/onsi/gomega v1.39.1
+	github.com/paketo-buildpacks/github-config/internal/github v0.0.0
 	github.com/sclevine/spec v1.4.0
 )
 
@@ -15,3 +16,5 @@ require (
 	golang.org/x/net v0.49.0 // indirect
 	golang.org/x/text v0.33.0 // indirect
 )
+
+replace github.com/paketo-buildpacks/github-config/internal/github => ../../../internal/github
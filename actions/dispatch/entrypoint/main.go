@@ -6,10 +6,11 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"net/http"
-	"net/http/httputil"
 	"os"
 	"strings"
+	"time"
+
+	"github.com/paketo-buildpacks/github-config/internal/github"
 )
 
 func main() {
@@ -18,16 +19,15 @@ func main() {
 	var config struct {
 		Endpoint string
 		Repos    string
-		Token    string
 		Event    string
 		Payload  string
 	}
 
-	flag.StringVar(&config.Endpoint, "endpoint", "https://api.github.com", "Specifies endpoint for sending dispatch request")
+	flag.StringVar(&config.Endpoint, "endpoint", github.DefaultEndpoint, "Specifies endpoint for sending dispatch request")
 	flag.StringVar(&config.Repos, "repos", "", "Specifies comma separated list of repos for sending dispatch request")
-	flag.StringVar(&config.Token, "token", "", "Github Authorization Token")
 	flag.StringVar(&config.Event, "event", "", "event type sent with the dispatch")
 	flag.StringVar(&config.Payload, "payload", "", "payload sent with the dispatch")
+	clientFlags := github.RegisterClientFlags(flag.CommandLine, github.ActionClientEnv, 10*time.Minute)
 	flag.Parse()
 
 	if config.Event == "" {
@@ -42,7 +42,7 @@ func main() {
 		fail(errors.New("missing required input \"repos\""))
 	}
 
-	if config.Token == "" {
+	if !clientFlags.HasCredentials() {
 		fail(errors.New("missing required input \"token\""))
 	}
 
@@ -59,28 +59,30 @@ func main() {
 		fail(err)
 	}
 
+	client, err := clientFlags.NewClient(config.Endpoint)
+	if err != nil {
+		fail(err)
+	}
+
 	repos := strings.Split(config.Repos, ",")
 
 	for _, r := range repos {
 		repo := strings.TrimSpace(r)
 		fmt.Printf("  Repository: %s\n", repo)
-		req, err := http.NewRequest("POST", fmt.Sprintf("%s/repos/%s/dispatches", config.Endpoint, repo), bytes.NewBuffer(payloadData))
+		req, err := client.NewRequest("POST", fmt.Sprintf("/repos/%s/dispatches", repo), bytes.NewBuffer(payloadData))
 		if err != nil {
 			fail(fmt.Errorf("failed to create dispatch request: %w", err))
 		}
 
-		req.Header.Set("Authorization", fmt.Sprintf("token %s", config.Token))
-
-		resp, err := http.DefaultClient.Do(req)
+		_, err = client.Do(req, nil)
 		if err != nil {
+			var apiErr *github.Error
+			if errors.As(err, &apiErr) {
+				fail(fmt.Errorf("unexpected response from dispatch request: %w", err))
+			}
 			fail(fmt.Errorf("failed to complete dispatch request: %w", err))
 		}
 
-		if resp.StatusCode != http.StatusNoContent {
-			dump, _ := httputil.DumpResponse(resp, true)
-			fail(fmt.Errorf("unexpected response from dispatch request: %s", dump))
-		}
-
 		fmt.Println("Success!")
 	}
 }
@@ -3,11 +3,16 @@ package main_test
 import (
 	"bufio"
 	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/http/httputil"
+	"os"
 	"os/exec"
 	"strings"
 	"testing"
@@ -42,10 +47,12 @@ func TestEntrypoint(t *testing.T) {
 			var (
 				api      *httptest.Server
 				requests []*http.Request
+				limited  bool
 			)
 
 			it.Before(func() {
 				requests = []*http.Request{}
+				limited = true
 				api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 					dump, _ := httputil.DumpRequest(req, true)
 					receivedRequest, _ := http.ReadRequest(bufio.NewReader(bytes.NewBuffer(dump)))
@@ -60,12 +67,30 @@ func TestEntrypoint(t *testing.T) {
 					}
 
 					switch req.URL.Path {
+					case "/app/installations/some-installation-id/access_tokens":
+						if !strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ") {
+							w.WriteHeader(http.StatusUnauthorized)
+							return
+						}
+						w.WriteHeader(http.StatusCreated)
+						fmt.Fprintf(w, `{"token": "some-github-token", "expires_at": %q}`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+
 					case "/repos/some-org/some-repo/dispatches":
 						w.WriteHeader(http.StatusNoContent)
 
 					case "/repos/some-org/some-other-repo/dispatches":
 						w.WriteHeader(http.StatusNoContent)
 
+					case "/repos/some-org/rate-limited-repo/dispatches":
+						if limited {
+							limited = false
+							w.Header().Set("Retry-After", "1")
+							w.WriteHeader(http.StatusTooManyRequests)
+							w.Write([]byte(`{"message": "You have exceeded a secondary rate limit."}`))
+							return
+						}
+						w.WriteHeader(http.StatusNoContent)
+
 					case "/repos/loop-org/loop-repo/dispatches":
 						w.Header().Set("Location", "/repos/loop-org/loop-repo/dispatches")
 						w.WriteHeader(http.StatusFound)
@@ -166,6 +191,108 @@ func TestEntrypoint(t *testing.T) {
 				})
 			})
 
+			context("when authenticating as a GitHub App", func() {
+				var privateKey string
+
+				it.Before(func() {
+					key, err := rsa.GenerateKey(rand.Reader, 2048)
+					Expect(err).NotTo(HaveOccurred())
+
+					privateKey = string(pem.EncodeToMemory(&pem.Block{
+						Type:  "RSA PRIVATE KEY",
+						Bytes: x509.MarshalPKCS1PrivateKey(key),
+					}))
+				})
+
+				it("mints an installation token once and uses it for every repo", func() {
+					command := exec.Command(
+						entrypoint,
+						"--endpoint", api.URL,
+						"--repos", "some-org/some-repo,some-org/some-other-repo",
+						"--app-id", "some-app-id",
+						"--app-installation-id", "some-installation-id",
+						"--event", "some-event",
+						"--payload", `{"key": "value"}`,
+					)
+					command.Env = append(os.Environ(), fmt.Sprintf("APP_PRIVATE_KEY=%s", privateKey))
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output:\n%s\n", buffer.Contents()) })
+
+					Expect(buffer).To(gbytes.Say(`  Repository: some-org/some-repo`))
+					Expect(buffer).To(gbytes.Say(`Success!`))
+					Expect(buffer).To(gbytes.Say(`  Repository: some-org/some-other-repo`))
+					Expect(buffer).To(gbytes.Say(`Success!`))
+
+					Expect(requests).To(HaveLen(3))
+					Expect(requests[0].URL.Path).To(Equal("/app/installations/some-installation-id/access_tokens"))
+					Expect(requests[1].URL.Path).To(Equal("/repos/some-org/some-repo/dispatches"))
+					Expect(requests[2].URL.Path).To(Equal("/repos/some-org/some-other-repo/dispatches"))
+				})
+
+				context("when the private key is invalid", func() {
+					it("prints an error message and exits non-zero", func() {
+						command := exec.Command(
+							entrypoint,
+							"--endpoint", api.URL,
+							"--repos", "some-org/some-repo",
+							"--app-id", "some-app-id",
+							"--app-installation-id", "some-installation-id",
+							"--event", "some-event",
+							"--payload", `{"key": "value"}`,
+						)
+						command.Env = append(os.Environ(), "APP_PRIVATE_KEY=not-a-key")
+						buffer := gbytes.NewBuffer()
+
+						session, err := gexec.Start(command, buffer, buffer)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output:\n%s\n", buffer.Contents()) })
+
+						Expect(buffer).To(gbytes.Say(`Error: failed to parse GitHub App private key: no PEM data found`))
+					})
+				})
+			})
+
+			context("when a target repo hits a rate limit", func() {
+				it("waits and resumes dispatching", func() {
+					command := exec.Command(
+						entrypoint,
+						"--endpoint", api.URL,
+						"--repos", "some-org/rate-limited-repo,some-org/some-repo",
+						"--token", "some-github-token",
+						"--event", "some-event",
+						"--payload", `{"key": "value"}`,
+					)
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output:\n%s\n", buffer.Contents()) })
+
+					Expect(buffer).To(gbytes.Say(`  Repository: some-org/rate-limited-repo`))
+					Expect(buffer).To(gbytes.Say(`Hit secondary rate limit on POST .*/repos/some-org/rate-limited-repo/dispatches, waiting 1s`))
+					Expect(buffer).To(gbytes.Say(`Success!`))
+					Expect(buffer).To(gbytes.Say(`  Repository: some-org/some-repo`))
+					Expect(buffer).To(gbytes.Say(`Success!`))
+
+					Expect(requests).To(HaveLen(3))
+
+					body, err := io.ReadAll(requests[1].Body)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(body)).To(MatchJSON(`{
+						"event_type": "some-event",
+						"client_payload": {
+							"key": "value"
+						}
+					}`))
+				})
+			})
+
 			context("failure cases", func() {
 				context("when the --event flag is missing", func() {
 					it("prints an error message and exits non-zero", func() {
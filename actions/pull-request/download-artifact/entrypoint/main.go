@@ -3,13 +3,16 @@ package main
 import (
 	"archive/zip"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/paketo-buildpacks/github-config/internal/github"
 )
 
 type Options struct {
@@ -19,7 +22,6 @@ type Options struct {
 	RunID     string
 	GithubAPI string
 	Workspace string
-	Token     string
 }
 
 func main() {
@@ -29,9 +31,9 @@ func main() {
 	flag.StringVar(&options.Glob, "glob", "*", "Name of the file of interest inside the artifact zip")
 	flag.StringVar(&options.Repo, "repo", "", "Org and repository that the workflow lives in")
 	flag.StringVar(&options.RunID, "run-id", "", "ID of the specific workflow that contains the artifact")
-	flag.StringVar(&options.GithubAPI, "github-api", "https://api.github.com", "Github API endpoint to query for the download")
+	flag.StringVar(&options.GithubAPI, "github-api", github.DefaultEndpoint, "Github API endpoint to query for the download")
 	flag.StringVar(&options.Workspace, "workspace", "", "Path to the workspace to put artifacts")
-	flag.StringVar(&options.Token, "token", "", "Github Access Token used to make the request")
+	clientFlags := github.RegisterClientFlags(flag.CommandLine, github.ActionClientEnv, 10*time.Minute)
 	flag.Parse()
 
 	requiredFlags := map[string]string{
@@ -39,7 +41,6 @@ func main() {
 		"--repo":      options.Repo,
 		"--run-id":    options.RunID,
 		"--workspace": options.Workspace,
-		"--token":     options.Token,
 	}
 
 	for name, value := range requiredFlags {
@@ -48,12 +49,21 @@ func main() {
 		}
 	}
 
-	url, size, err := GetWorkflowArtifactURL(options.GithubAPI, options.Repo, options.RunID, options.Token, options.Name)
+	if !clientFlags.HasCredentials() {
+		fail(errors.New("missing required flag --token"))
+	}
+
+	client, err := clientFlags.NewClient(options.GithubAPI)
+	if err != nil {
+		fail(err)
+	}
+
+	url, size, err := GetWorkflowArtifactURL(client, options.Repo, options.RunID, options.Name)
 	if err != nil {
 		fail(err)
 	}
 
-	body, err := GetArtifactZip(url, options.Token)
+	body, err := GetArtifactZip(client, url)
 	if err != nil {
 		fail(err)
 	}
@@ -70,26 +80,23 @@ func fail(err error) {
 	os.Exit(1)
 }
 
-func GetWorkflowArtifactURL(api, repo, runID, token, name string) (string, int, error) {
-	url := fmt.Sprintf("%s/repos/%s/actions/runs/%s/artifacts", api, repo, runID)
-	req, err := http.NewRequest("GET", url, nil)
+func GetWorkflowArtifactURL(client *github.Client, repo, runID, name string) (string, int, error) {
+	req, err := client.NewRequest("GET", fmt.Sprintf("/repos/%s/actions/runs/%s/artifacts", repo, runID), nil)
 	if err != nil {
 		return "", 0, err
 	}
 
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
-
-	fmt.Printf("Getting workflow artifacts from %s\n", url)
-	resp, err := http.DefaultClient.Do(req)
+	fmt.Printf("Getting workflow artifacts from %s\n", req.URL)
+	resp, err := client.BareDo(req)
 	if err != nil {
+		var apiErr *github.Error
+		if errors.As(err, &apiErr) {
+			return "", 0, fmt.Errorf("failed to list artifacts: status code %d", apiErr.StatusCode)
+		}
 		return "", 0, fmt.Errorf("failed to list artifacts: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", 0, fmt.Errorf("failed to list artifacts: status code %d", resp.StatusCode)
-	}
-
 	var body struct {
 		Artifacts []struct {
 			Name               string `json:"name"`
@@ -111,25 +118,22 @@ func GetWorkflowArtifactURL(api, repo, runID, token, name string) (string, int,
 	return "", 0, fmt.Errorf("failed to find matching artifact")
 }
 
-func GetArtifactZip(url, token string) (io.ReadCloser, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func GetArtifactZip(client *github.Client, url string) (io.ReadCloser, error) {
+	req, err := client.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
-
 	fmt.Printf("Downloading zip from %s\n", url)
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.BareDo(req)
 	if err != nil {
+		var apiErr *github.Error
+		if errors.As(err, &apiErr) {
+			return nil, fmt.Errorf("failed to get artifact zip file: status code %d", apiErr.StatusCode)
+		}
 		return nil, fmt.Errorf("failed to get artifact zip file: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		return nil, fmt.Errorf("failed to get artifact zip file: status code %d", resp.StatusCode)
-	}
-
 	return resp.Body, nil
 }
 
@@ -6,8 +6,6 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -15,6 +13,7 @@ import (
 	"time"
 
 	backoff "github.com/cenkalti/backoff/v4"
+	"github.com/paketo-buildpacks/github-config/internal/github"
 )
 
 type Release struct {
@@ -29,7 +28,6 @@ func main() {
 	var config struct {
 		Endpoint       string
 		Repo           string
-		Token          string
 		Release        Release
 		Draft          bool
 		Assets         string
@@ -37,9 +35,8 @@ func main() {
 		BodyFilepath   string
 	}
 
-	flag.StringVar(&config.Endpoint, "endpoint", "https://api.github.com", "Specifies endpoint for sending requests")
+	flag.StringVar(&config.Endpoint, "endpoint", github.DefaultEndpoint, "Specifies endpoint for sending requests")
 	flag.StringVar(&config.Repo, "repo", "", "Specifies repo for sending requests")
-	flag.StringVar(&config.Token, "token", "", "Github Authorization Token")
 	flag.StringVar(&config.Release.TagName, "tag-name", "", "Name of the tag for the release")
 	flag.StringVar(&config.Release.TargetCommitish, "target-commitish", "", "Commitish that is being tagged and released")
 	flag.StringVar(&config.Release.Name, "name", "", "Name of release")
@@ -48,13 +45,14 @@ func main() {
 	flag.BoolVar(&config.Draft, "draft", false, "Sets the release as a draft")
 	flag.StringVar(&config.Assets, "assets", "", "JSON-encoded assets metadata")
 	flag.StringVar(&config.RetryTimeLimit, "retry-time-limit", "1m", "How long to retry failures for")
+	clientFlags := github.RegisterClientFlags(flag.CommandLine, github.ActionClientEnv, 10*time.Minute)
 	flag.Parse()
 
 	if config.Repo == "" {
 		fail(errors.New(`missing required input "repo"`))
 	}
 
-	if config.Token == "" {
+	if !clientFlags.HasCredentials() {
 		fail(errors.New(`missing required input "token"`))
 	}
 
@@ -108,25 +106,27 @@ func main() {
 		fail(fmt.Errorf("failed to encode release: %w", err))
 	}
 
+	client, err := clientFlags.NewClient(config.Endpoint)
+	if err != nil {
+		fail(err)
+	}
+
 	fmt.Println("Creating release")
 	fmt.Printf("  Repository: %s\n", config.Repo)
-	uri := fmt.Sprintf("%s/repos/%s/releases", config.Endpoint, config.Repo)
-	req, err := http.NewRequest("POST", uri, body)
+	req, err := client.NewRequest("POST", fmt.Sprintf("/repos/%s/releases", config.Repo), body)
 	if err != nil {
 		fail(fmt.Errorf("failed to create request: %w", err))
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("token %s", config.Token))
-
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.BareDo(req)
 	if err != nil {
+		var apiErr *github.Error
+		if errors.As(err, &apiErr) {
+			fail(fmt.Errorf("failed to create release: unexpected response: %w", err))
+		}
 		fail(fmt.Errorf("failed to complete request: %w", err))
 	}
-
-	if resp.StatusCode != http.StatusCreated {
-		dump, _ := httputil.DumpResponse(resp, true)
-		fail(fmt.Errorf("failed to create release: unexpected response: %s", dump))
-	}
+	defer resp.Body.Close()
 
 	var release struct {
 		ID        int    `json:"id"`
@@ -160,27 +160,24 @@ func main() {
 				return fmt.Errorf("failed to stat file: %w", err)
 			}
 
-			req, err = http.NewRequest("POST", uri.String(), file)
+			req, err = client.NewRequest("POST", uri.String(), file)
 			if err != nil {
 				return fmt.Errorf("failed to create request: %w", err)
 			}
 
-			req.Header.Set("Authorization", fmt.Sprintf("token %s", config.Token))
-
 			req.ContentLength = info.Size()
 			req.Header.Set("Content-Type", asset.ContentType)
 
 			fmt.Printf("  Uploading asset: %s -> %s\n", asset.Path, asset.Name)
-			resp, err = http.DefaultClient.Do(req)
+			_, err = client.Do(req, nil)
 			if err != nil {
+				var apiErr *github.Error
+				if errors.As(err, &apiErr) {
+					return fmt.Errorf("failed to upload asset: unexpected response: %w", err)
+				}
 				return fmt.Errorf("failed to complete request: %w", err)
 			}
 
-			if resp.StatusCode != http.StatusCreated {
-				dump, _ := httputil.DumpResponse(resp, true)
-				return fmt.Errorf("failed to upload asset: unexpected response: %s", dump)
-			}
-
 			return nil
 		},
 			exponentialBackoff,
@@ -200,24 +197,20 @@ func main() {
 		return
 	}
 
-	uri = fmt.Sprintf("%s/repos/%s/releases/%d", config.Endpoint, config.Repo, release.ID)
-	req, err = http.NewRequest("PATCH", uri, strings.NewReader(`{"draft": false}`))
+	req, err = client.NewRequest("PATCH", fmt.Sprintf("/repos/%s/releases/%d", config.Repo, release.ID), strings.NewReader(`{"draft": false}`))
 	if err != nil {
 		fail(fmt.Errorf("failed to create request: %w", err))
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("token %s", config.Token))
-
-	resp, err = http.DefaultClient.Do(req)
+	_, err = client.Do(req, nil)
 	if err != nil {
+		var apiErr *github.Error
+		if errors.As(err, &apiErr) {
+			fail(fmt.Errorf("failed to edit release: unexpected response: %w", err))
+		}
 		fail(fmt.Errorf("failed to complete request: %w", err))
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		dump, _ := httputil.DumpResponse(resp, true)
-		fail(fmt.Errorf("failed to edit release: unexpected response: %s", dump))
-	}
-
 	fmt.Println("Release is published, exiting.")
 }
 
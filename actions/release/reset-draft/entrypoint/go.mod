@@ -6,6 +6,7 @@ toolchain go1.26.3
 
 require (
 	github.com/onsi/gomega v1.39.1
+	github.com/paketo-buildpacks/github-config/internal/github v0.0.0
 	github.com/sclevine/spec v1.4.0
 )
 
@@ -17,3 +18,5 @@ require (
 	golang.org/x/text v0.33.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 )
+
+replace github.com/paketo-buildpacks/github-config/internal/github => ../../../../internal/github
@@ -5,52 +5,54 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"net/http"
-	"net/http/httputil"
 	"os"
+	"time"
+
+	"github.com/paketo-buildpacks/github-config/internal/github"
 )
 
 func main() {
 	var config struct {
 		Endpoint string
 		Repo     string
-		Token    string
 		Version  string
 	}
 
-	flag.StringVar(&config.Endpoint, "endpoint", "https://api.github.com", "Specifies endpoint for sending requests")
+	flag.StringVar(&config.Endpoint, "endpoint", github.DefaultEndpoint, "Specifies endpoint for sending requests")
 	flag.StringVar(&config.Repo, "repo", "", "Specifies repo for sending requests")
-	flag.StringVar(&config.Token, "token", "", "Github Authorization Token")
 	flag.StringVar(&config.Version, "version", "", "Optional specific release version to reset")
+	clientFlags := github.RegisterClientFlags(flag.CommandLine, github.ActionClientEnv, 10*time.Minute)
 	flag.Parse()
 
 	if config.Repo == "" {
 		fail(errors.New(`missing required input "repo"`))
 	}
 
-	if config.Token == "" {
+	if !clientFlags.HasCredentials() {
 		fail(errors.New(`missing required input "token"`))
 	}
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/repos/%s/releases", config.Endpoint, config.Repo), nil)
+	client, err := clientFlags.NewClient(config.Endpoint)
 	if err != nil {
 		fail(err)
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("token %s", config.Token))
+	req, err := client.NewRequest("GET", fmt.Sprintf("/repos/%s/releases", config.Repo), nil)
+	if err != nil {
+		fail(err)
+	}
 
 	fmt.Println(`Fetching latest releases`)
 	fmt.Printf("  Repository: %s\n", config.Repo)
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.BareDo(req)
 	if err != nil {
+		var apiErr *github.Error
+		if errors.As(err, &apiErr) {
+			fail(fmt.Errorf("unexpected response from list releases request: %w", err))
+		}
 		fail(err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		dump, _ := httputil.DumpResponse(resp, true)
-		fail(fmt.Errorf("unexpected response from list releases request: %s", dump))
-	}
-
 	type release struct {
 		ID      int    `json:"id"`
 		Draft   bool   `json:"draft"`
@@ -100,23 +102,20 @@ func main() {
 
 	fmt.Printf("Found draft with version: '%s', deleting\n", releaseToDelete.TagName)
 
-	req, err = http.NewRequest("DELETE", fmt.Sprintf("%s/repos/%s/releases/%d", config.Endpoint, config.Repo, releaseToDelete.ID), nil)
+	req, err = client.NewRequest("DELETE", fmt.Sprintf("/repos/%s/releases/%d", config.Repo, releaseToDelete.ID), nil)
 	if err != nil {
 		fail(err)
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("token %s", config.Token))
-
-	resp, err = http.DefaultClient.Do(req)
+	_, err = client.Do(req, nil)
 	if err != nil {
+		var apiErr *github.Error
+		if errors.As(err, &apiErr) {
+			fail(fmt.Errorf("unexpected response from delete draft release request: %w", err))
+		}
 		fail(err)
 	}
 
-	if resp.StatusCode != http.StatusNoContent {
-		dump, _ := httputil.DumpResponse(resp, true)
-		fail(fmt.Errorf("unexpected response from delete draft release request: %s", dump))
-	}
-
 	outputFileName, ok := os.LookupEnv("GITHUB_OUTPUT")
 	if !ok {
 		fail(errors.New("GITHUB_OUTPUT is not set, see https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#setting-an-output-parameter"))
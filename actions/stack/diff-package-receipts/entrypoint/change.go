@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/paketo-buildpacks/github-config/internal/debversion"
+)
+
+const (
+	// ChangeUpgrade means the current version is newer than the previous one
+	ChangeUpgrade = "upgrade"
+
+	// ChangeDowngrade means the current version is older than the previous one
+	ChangeDowngrade = "downgrade"
+
+	// ChangeRebuild means the version is the same, but the PURL changed, such
+	// as when a package was rebuilt from a different source version
+	ChangeRebuild = "rebuild"
+
+	// ChangeChecksum means only the checksum of the package changed
+	ChangeChecksum = "checksum"
+
+	// ChangeMetadata means the version and PURL are the same, but the license
+	// or supplier of the package changed, along with its checksum or not
+	ChangeMetadata = "metadata"
+
+	// ChangeUnknown means the versions could not be compared, as the package
+	// is not a Debian package or at least one of them is not a Debian version
+	ChangeUnknown = "unknown"
+)
+
+// classifyChange tells which way the version of a modified package went,
+// comparing the versions of Debian packages the same way as
+// dpkg --compare-versions, or what else changed when the version did not.
+// The versions of other packages are not compared, as every ecosystem orders
+// them its own way, such as 1.0rc1 coming before 1.0 in PyPI.
+func classifyChange(previous, current CycloneDXComponent) string {
+	if previous.Version == current.Version && previous.PURL == current.PURL {
+		if hashesChanged(previous, current) && !licenseChanged(previous, current) && !supplierChanged(previous, current) {
+			return ChangeChecksum
+		}
+		return ChangeMetadata
+	}
+
+	// the same version needs no ordering, whatever the ecosystem
+	if previous.Version == current.Version {
+		return ChangeRebuild
+	}
+
+	if !isDebianPackage(previous, current) {
+		return ChangeUnknown
+	}
+
+	result, err := debversion.Compare(current.Version, previous.Version)
+	if err != nil {
+		fmt.Printf("Failed to compare versions of %s: %v\n", current.Name, err)
+		return ChangeUnknown
+	}
+
+	switch {
+	case result > 0:
+		return ChangeUpgrade
+	case result < 0:
+		return ChangeDowngrade
+	default:
+		return ChangeRebuild
+	}
+}
+
+// isDebianPackage reports whether the paired components are a Debian
+// package, which at least one of them says by its deb PURL or by being read
+// from a dpkg status file, while the other has no PURL of another type.
+func isDebianPackage(previous, current CycloneDXComponent) bool {
+	previousType, currentType := packageType(previous), packageType(current)
+
+	return (previousType == "deb" || currentType == "deb") &&
+		(previousType == "deb" || previousType == "") &&
+		(currentType == "deb" || currentType == "")
+}
+
+// packageType returns the PURL type of the component, deb for packages read
+// from a dpkg status file, which have an architecture but no PURL, and
+// nothing when the type is not known.
+func packageType(component CycloneDXComponent) string {
+	identity, ok := purlIdentity(component.PURL)
+	if !ok {
+		if component.architecture != "" {
+			return "deb"
+		}
+		return ""
+	}
+
+	purlType, _, _ := strings.Cut(identity, "/")
+	return purlType
+}
@@ -1,3 +1,18 @@
 module github.com/paketo-buildpacks/github-config/actions/stack/diff-package-receipts/entrypoint
 
-go 1.18
+go 1.24.0
+
+require (
+	github.com/onsi/gomega v1.39.1
+	github.com/paketo-buildpacks/github-config/internal/debversion v0.0.0
+	github.com/sclevine/spec v1.4.0
+)
+
+require (
+	github.com/google/go-cmp v0.7.0 // indirect
+	go.yaml.in/yaml/v3 v3.0.4 // indirect
+	golang.org/x/net v0.49.0 // indirect
+	golang.org/x/text v0.33.0 // indirect
+)
+
+replace github.com/paketo-buildpacks/github-config/internal/debversion => ../../../../internal/debversion
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// identityQualifiers are the PURL qualifiers that tell packages of the same
+// name apart, such as the same deb built for two architectures. Qualifiers
+// that change between builds of a package, such as the upstream source
+// version of a deb, are left out.
+var identityQualifiers = map[string]bool{
+	"arch":       true,
+	"classifier": true,
+	"type":       true,
+}
+
+// caseInsensitiveTypes are the PURL types whose namespaces and names are
+// compared ignoring case, see
+// https://github.com/package-url/purl-spec/blob/main/PURL-TYPES.rst.
+var caseInsensitiveTypes = map[string]bool{
+	"apk":       true,
+	"bitbucket": true,
+	"deb":       true,
+	"github":    true,
+	"npm":       true,
+	"pypi":      true,
+}
+
+// packageIdentity returns what identifies a component across receipts: its
+// PURL without its version, subpath and build specific qualifiers. A
+// component without a valid PURL is identified by its name alone.
+func packageIdentity(component CycloneDXComponent) string {
+	identity, ok := purlIdentity(component.PURL)
+	if !ok {
+		return "name:" + component.Name
+	}
+
+	return identity
+}
+
+// purlIdentity normalizes a PURL of the form
+// pkg:type/namespace/name@version?qualifiers#subpath, see
+// https://github.com/package-url/purl-spec/blob/main/PURL-SPECIFICATION.rst.
+func purlIdentity(purl string) (string, bool) {
+	if len(purl) < 4 || !strings.EqualFold(purl[:4], "pkg:") {
+		return "", false
+	}
+	rest := strings.TrimLeft(purl[4:], "/")
+
+	if i := strings.Index(rest, "#"); i >= 0 {
+		rest = rest[:i]
+	}
+
+	var rawQualifiers string
+	if i := strings.Index(rest, "?"); i >= 0 {
+		rest, rawQualifiers = rest[:i], rest[i+1:]
+	}
+
+	if i := strings.LastIndex(rest, "@"); i >= 0 && i > strings.LastIndex(rest, "/") {
+		rest = rest[:i]
+	}
+
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	if len(segments) < 2 {
+		return "", false
+	}
+
+	purlType := strings.ToLower(segments[0])
+	for i, segment := range segments[1:] {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return "", false
+		}
+
+		if caseInsensitiveTypes[purlType] {
+			unescaped = strings.ToLower(unescaped)
+		}
+		segments[i+1] = unescaped
+	}
+
+	name := segments[len(segments)-1]
+	if name == "" {
+		return "", false
+	}
+
+	// PyPI treats dashes and underscores in names the same
+	if purlType == "pypi" {
+		segments[len(segments)-1] = strings.ReplaceAll(name, "_", "-")
+	}
+
+	qualifiers, err := url.ParseQuery(rawQualifiers)
+	if err != nil {
+		return "", false
+	}
+
+	var kept []string
+	for key, values := range qualifiers {
+		key = strings.ToLower(key)
+		if identityQualifiers[key] && len(values) > 0 && values[0] != "" {
+			kept = append(kept, key+"="+values[0])
+		}
+	}
+	sort.Strings(kept)
+
+	identity := purlType + "/" + strings.Join(segments[1:], "/")
+	if len(kept) > 0 {
+		identity += "?" + strings.Join(kept, "&")
+	}
+
+	return identity, true
+}
+
+// diffComponents pairs the components of the receipts by their identities.
+// Components left over are then paired by name and architecture, and then by
+// name alone, as long as one of the two has no PURL and no other component
+// left over in either receipt has that name, such as when a package only has
+// a PURL in the newer receipt.
+func diffComponents(previous, current []CycloneDXComponent) ([]CycloneDXComponent, []CycloneDXComponent, []ModifiedCycloneDXComponent) {
+	pairs, removed, added := pairComponents(previous, current, packageIdentity, false)
+
+	byArchitecture, removed, added := pairComponents(removed, added, nameAndArchitecture, true)
+	pairs = append(pairs, byArchitecture...)
+
+	byName, removed, added := pairComponents(removed, added, func(c CycloneDXComponent) string { return baseName(c) }, true)
+	pairs = append(pairs, byName...)
+
+	var modified []ModifiedCycloneDXComponent
+	for _, pair := range pairs {
+		if changed(pair[0], pair[1]) {
+			modified = append(modified, newModifiedComponent(pair[0], pair[1]))
+		}
+	}
+
+	return sortComponents(added), sortComponents(removed), sortModifiedComponents(modified)
+}
+
+// pairComponents pairs the previous and current components with the same
+// key, in the order they are given, returning those left over. When fallback
+// is set, components are only paired when each is the only one with its key
+// and at least one of them has no PURL.
+func pairComponents(previous, current []CycloneDXComponent, key func(CycloneDXComponent) string, fallback bool) ([][2]CycloneDXComponent, []CycloneDXComponent, []CycloneDXComponent) {
+	previousByKey := map[string][]CycloneDXComponent{}
+	for _, component := range previous {
+		previousByKey[key(component)] = append(previousByKey[key(component)], component)
+	}
+
+	currentByKey := map[string][]CycloneDXComponent{}
+	for _, component := range current {
+		currentByKey[key(component)] = append(currentByKey[key(component)], component)
+	}
+
+	var pairs [][2]CycloneDXComponent
+	var unpairedPrevious []CycloneDXComponent
+	paired := map[string]int{}
+	for _, prevPackage := range previous {
+		k := key(prevPackage)
+		candidates := currentByKey[k]
+		if fallback && !canPairByName(previousByKey[k], candidates) || paired[k] >= len(candidates) {
+			// package in previous but not in current
+			unpairedPrevious = append(unpairedPrevious, prevPackage)
+			continue
+		}
+
+		pairs = append(pairs, [2]CycloneDXComponent{prevPackage, candidates[paired[k]]})
+		paired[k]++
+	}
+
+	var unpairedCurrent []CycloneDXComponent
+	seen := map[string]int{}
+	for _, curPackage := range current {
+		k := key(curPackage)
+		seen[k]++
+		if seen[k] > paired[k] {
+			// package appears in current, not in previous
+			unpairedCurrent = append(unpairedCurrent, curPackage)
+		}
+	}
+
+	return pairs, unpairedPrevious, unpairedCurrent
+}
+
+func canPairByName(previous, current []CycloneDXComponent) bool {
+	if len(previous) != 1 || len(current) != 1 {
+		return false
+	}
+
+	_, previousHasPURL := purlIdentity(previous[0].PURL)
+	_, currentHasPURL := purlIdentity(current[0].PURL)
+
+	return !previousHasPURL || !currentHasPURL
+}
+
+// baseName returns the name of the component without the architecture dpkg
+// adds to the names of packages installed for more than one architecture.
+func baseName(component CycloneDXComponent) string {
+	if component.architecture == "" {
+		return component.Name
+	}
+
+	return strings.TrimSuffix(component.Name, ":"+component.architecture)
+}
+
+// nameAndArchitecture returns the name of the component along with its
+// architecture, read from the arch qualifier of its PURL when it has one.
+func nameAndArchitecture(component CycloneDXComponent) string {
+	architecture := component.architecture
+	if architecture == "" {
+		if i := strings.Index(component.PURL, "?"); i >= 0 {
+			qualifiers, err := url.ParseQuery(component.PURL[i+1:])
+			if err == nil {
+				architecture = qualifiers.Get("arch")
+			}
+		}
+	}
+
+	return baseName(component) + "|" + architecture
+}
+
+func changed(previous, current CycloneDXComponent) bool {
+	return previous.Version != current.Version ||
+		previous.PURL != current.PURL ||
+		hashesChanged(previous, current) ||
+		licenseChanged(previous, current) ||
+		supplierChanged(previous, current)
+}
+
+func newModifiedComponent(previous, current CycloneDXComponent) ModifiedCycloneDXComponent {
+	return ModifiedCycloneDXComponent{
+		Name:            current.Name,
+		PreviousVersion: previous.Version,
+		PreviousPURL:    previous.PURL,
+		CurrentVersion:  current.Version,
+		CurrentPURL:     current.PURL,
+		Change:          classifyChange(previous, current),
+
+		PreviousLicense:  licenseExpression(previous),
+		CurrentLicense:   licenseExpression(current),
+		LicenseChanged:   licenseChanged(previous, current),
+		PreviousSupplier: supplierName(previous),
+		CurrentSupplier:  supplierName(current),
+		SupplierChanged:  supplierChanged(previous, current),
+	}
+}
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 type CycloneDXPackageList struct {
@@ -17,6 +19,13 @@ type CycloneDXComponent struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
 	PURL    string `json:"purl"`
+
+	Licenses []CycloneDXLicenseChoice `json:"licenses,omitempty"`
+	Supplier *CycloneDXOrganization   `json:"supplier,omitempty"`
+	Hashes   []CycloneDXHash          `json:"hashes,omitempty"`
+
+	// architecture is set for packages read from receipts without PURLs
+	architecture string
 }
 
 type ModifiedCycloneDXComponent struct {
@@ -25,6 +34,14 @@ type ModifiedCycloneDXComponent struct {
 	CurrentVersion  string `json:"currentVersion"`
 	PreviousPURL    string `json:"previousPurl"`
 	CurrentPURL     string `json:"currentPurl"`
+	Change          string `json:"change"`
+
+	PreviousLicense  string `json:"previousLicense,omitempty"`
+	CurrentLicense   string `json:"currentLicense,omitempty"`
+	LicenseChanged   bool   `json:"licenseChanged,omitempty"`
+	PreviousSupplier string `json:"previousSupplier,omitempty"`
+	CurrentSupplier  string `json:"currentSupplier,omitempty"`
+	SupplierChanged  bool   `json:"supplierChanged,omitempty"`
 }
 
 func main() {
@@ -34,6 +51,10 @@ func main() {
 		AddedDiffFilePath    string
 		RemovedDiffFilePath  string
 		ModifiedDiffFilePath string
+		OutputPath           string
+		MarkdownOutputPath   string
+		FailOnDowngrade      bool
+		DenyLicenses         string
 	}
 
 	flag.StringVar(&config.PreviousPath,
@@ -61,155 +82,187 @@ func main() {
 		"",
 		"List of packages modified")
 
-	flag.Parse()
-
-	if config.CurrentPath == "" || config.PreviousPath == "" {
-		log.Fatal("Must provide current and previous paths")
-	}
+	flag.StringVar(&config.OutputPath,
+		"output",
+		"",
+		"Path to write the diff to as a single JSON document, with sorted lists, summary counts and receipt digests")
 
-	if config.AddedDiffFilePath == "" || config.RemovedDiffFilePath == "" || config.ModifiedDiffFilePath == "" {
-		log.Fatal("Must provide diff file paths")
-	}
+	flag.StringVar(&config.MarkdownOutputPath,
+		"markdown-output",
+		"",
+		"Path to write the diff to as markdown")
 
-	absolute, err := filepath.Abs(config.CurrentPath)
-	if err != nil {
-		log.Fatalf("Failed to create absolute path for %s", config.CurrentPath)
-	}
-	config.CurrentPath = absolute
+	flag.BoolVar(&config.FailOnDowngrade,
+		"fail-on-downgrade",
+		false,
+		"Exit non-zero when a package was downgraded")
 
-	absolute, err = filepath.Abs(config.PreviousPath)
-	if err != nil {
-		log.Fatalf("Failed to create absolute path for %s", config.PreviousPath)
-	}
-	config.PreviousPath = absolute
+	flag.StringVar(&config.DenyLicenses,
+		"deny-licenses",
+		"",
+		"Comma separated list of SPDX license identifiers, exit non-zero when an added or modified package has one of them, unless its license expression offers another license with OR")
 
-	absolute, err = filepath.Abs(config.AddedDiffFilePath)
-	if err != nil {
-		log.Fatalf("Failed to create absolute path for %s", config.AddedDiffFilePath)
-	}
-	config.AddedDiffFilePath = absolute
+	flag.Parse()
 
-	absolute, err = filepath.Abs(config.RemovedDiffFilePath)
-	if err != nil {
-		log.Fatalf("Failed to create absolute path for %s", config.RemovedDiffFilePath)
+	if config.CurrentPath == "" || config.PreviousPath == "" {
+		log.Fatal("Must provide current and previous paths")
 	}
-	config.RemovedDiffFilePath = absolute
 
-	absolute, err = filepath.Abs(config.ModifiedDiffFilePath)
-	if err != nil {
-		log.Fatalf("Failed to create absolute path for %s", config.ModifiedDiffFilePath)
+	diffFiles := []string{config.AddedDiffFilePath, config.RemovedDiffFilePath, config.ModifiedDiffFilePath}
+	writeDiffFiles := config.AddedDiffFilePath != "" || config.RemovedDiffFilePath != "" || config.ModifiedDiffFilePath != ""
+	for _, path := range diffFiles {
+		if writeDiffFiles && path == "" {
+			log.Fatal("Must provide all of the diff file paths")
+		}
 	}
-	config.ModifiedDiffFilePath = absolute
 
-	previous, err := parsePackagesFromFile(config.PreviousPath)
-	if err != nil {
-		log.Fatal(err)
-	}
-	current, err := parsePackagesFromFile(config.CurrentPath)
-	if err != nil {
-		log.Fatal(err)
+	if !writeDiffFiles && config.OutputPath == "" && config.MarkdownOutputPath == "" {
+		log.Fatal("Must provide diff file paths, or an output or markdown output path")
 	}
 
-	var added, removed []CycloneDXComponent
-	var modified []ModifiedCycloneDXComponent
-	for prevName, prevPackage := range previous {
-		if _, ok := current[prevName]; !ok {
-			// package in previous but not in current
-			removed = append(removed, prevPackage)
+	// the receipts are described by the paths given, which do not depend on
+	// where the diff was made
+	previousPath, currentPath := config.PreviousPath, config.CurrentPath
+
+	for _, path := range []*string{
+		&config.CurrentPath,
+		&config.PreviousPath,
+		&config.AddedDiffFilePath,
+		&config.RemovedDiffFilePath,
+		&config.ModifiedDiffFilePath,
+		&config.OutputPath,
+		&config.MarkdownOutputPath,
+	} {
+		if *path == "" {
 			continue
 		}
-		// package appears in both previous and current
-		curPackage := current[prevName]
-		if prevPackage.Version != curPackage.Version || prevPackage.PURL != curPackage.PURL {
-			// package metadata has changed
-			modified = append(modified, ModifiedCycloneDXComponent{
-				Name:            curPackage.Name,
-				PreviousVersion: prevPackage.Version,
-				PreviousPURL:    prevPackage.PURL,
-				CurrentVersion:  curPackage.Version,
-				CurrentPURL:     curPackage.PURL,
-			})
-		}
-	}
 
-	for curName, curPackage := range current {
-		if _, ok := previous[curName]; !ok {
-			// package appears in current, not in previous
-			added = append(added, curPackage)
+		absolute, err := filepath.Abs(*path)
+		if err != nil {
+			log.Fatalf("Failed to create absolute path for %s", *path)
 		}
+		*path = absolute
 	}
 
-	addedFile, err := os.OpenFile(config.AddedDiffFilePath, os.O_RDWR|os.O_CREATE, 0666)
+	previous, previousSummary, err := parsePackagesFromFile(config.PreviousPath)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer addedFile.Close()
+	previousSummary.Path = previousPath
 
-	err = json.NewEncoder(addedFile).Encode(&added)
+	current, currentSummary, err := parsePackagesFromFile(config.CurrentPath)
 	if err != nil {
 		log.Fatal(err)
 	}
+	currentSummary.Path = currentPath
 
-	removedFile, err := os.OpenFile(config.RemovedDiffFilePath, os.O_RDWR|os.O_CREATE, 0666)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer removedFile.Close()
+	added, removed, modified := diffComponents(previous, current)
 
-	err = json.NewEncoder(removedFile).Encode(&removed)
-	if err != nil {
-		log.Fatal(err)
+	if writeDiffFiles {
+		err := writeJSONFile(config.AddedDiffFilePath, added)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		err = writeJSONFile(config.RemovedDiffFilePath, removed)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		err = writeJSONFile(config.ModifiedDiffFilePath, modified)
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 
-	modifiedFile, err := os.OpenFile(config.ModifiedDiffFilePath, os.O_RDWR|os.O_CREATE, 0666)
-	if err != nil {
-		log.Fatal(err)
+	document := newDiffDocument(previousSummary, currentSummary, added, removed, modified)
+
+	if config.OutputPath != "" {
+		err := writeJSONDocument(config.OutputPath, document)
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
-	defer modifiedFile.Close()
 
-	err = json.NewEncoder(modifiedFile).Encode(&modified)
-	if err != nil {
-		log.Fatal(err)
+	if config.MarkdownOutputPath != "" {
+		err := os.WriteFile(config.MarkdownOutputPath, []byte(renderMarkdown(document)), 0666)
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 
 	fmt.Println("Added packages:")
+	denied := parseLicenseList(config.DenyLicenses)
+	var deniedPackages []string
 	for _, pkg := range added {
 		fmt.Println(pkg.Name, pkg.Version)
+
+		if licenses := deniedLicenses(licenseExpression(pkg), denied); len(licenses) > 0 {
+			deniedPackages = append(deniedPackages, fmt.Sprintf("%s (%s)", pkg.Name, strings.Join(licenses, ", ")))
+		}
 	}
 	fmt.Println("Removed packages:")
 	for _, pkg := range removed {
 		fmt.Println(pkg.Name, pkg.Version)
 	}
 	fmt.Println("Modified packages:")
+	var downgraded []string
 	for _, pkg := range modified {
-		fmt.Printf("%[1]s %[2]s (PURL: %[3]s) => %[1]s %[4]s (PURL: %[5]s)\n",
+		fmt.Printf("%[1]s %[2]s (PURL: %[3]s) => %[1]s %[4]s (PURL: %[5]s) [%[6]s]\n",
 			pkg.Name,
 			pkg.PreviousVersion,
 			pkg.PreviousPURL,
 			pkg.CurrentVersion,
 			pkg.CurrentPURL,
+			pkg.Change,
 		)
+
+		if pkg.LicenseChanged {
+			fmt.Printf("  license: %s => %s\n", pkg.PreviousLicense, pkg.CurrentLicense)
+		}
+		if pkg.SupplierChanged {
+			fmt.Printf("  supplier: %s => %s\n", pkg.PreviousSupplier, pkg.CurrentSupplier)
+		}
+
+		if licenses := deniedLicenses(pkg.CurrentLicense, denied); len(licenses) > 0 {
+			deniedPackages = append(deniedPackages, fmt.Sprintf("%s (%s)", pkg.Name, strings.Join(licenses, ", ")))
+		}
+
+		if pkg.Change == ChangeDowngrade {
+			downgraded = append(downgraded, fmt.Sprintf("%s %s => %s", pkg.Name, pkg.PreviousVersion, pkg.CurrentVersion))
+		}
+	}
+
+	if config.FailOnDowngrade && len(downgraded) > 0 {
+		log.Fatalf("Found %d downgraded packages: %s", len(downgraded), strings.Join(downgraded, ", "))
+	}
+
+	if len(deniedPackages) > 0 {
+		log.Fatalf("Found %d packages with denied licenses: %s", len(deniedPackages), strings.Join(deniedPackages, ", "))
 	}
 }
 
-func parsePackagesFromFile(path string) (map[string]CycloneDXComponent, error) {
-	f, err := os.Open(path)
+func parsePackagesFromFile(path string) ([]CycloneDXComponent, ReceiptSummary, error) {
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to open %s: %w", path, err)
+		return nil, ReceiptSummary{}, fmt.Errorf("Failed to open %s: %w", path, err)
 	}
-	defer f.Close()
 
-	dec := json.NewDecoder(f)
-	var c CycloneDXPackageList
-	err = dec.Decode(&c)
+	components, format, err := readReceipt(content)
 	if err != nil {
-		return nil, err
+		return nil, ReceiptSummary{}, fmt.Errorf("Failed to parse %s: %w", path, err)
 	}
+	fmt.Printf("Read %d packages from %s receipt %s\n", len(components), format, path)
 
-	packages := make(map[string]CycloneDXComponent)
-	for _, component := range c.Components {
-		packages[component.Name] = component
+	return components, ReceiptSummary{Format: format, SHA256: fmt.Sprintf("%x", sha256.Sum256(content))}, nil
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
 	}
+	defer file.Close()
 
-	return packages, nil
+	return json.NewEncoder(file).Encode(v)
 }
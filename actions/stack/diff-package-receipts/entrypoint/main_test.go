@@ -0,0 +1,512 @@
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+type component struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	PURL    string `json:"purl"`
+}
+
+type modifiedComponent struct {
+	Name            string `json:"name"`
+	PreviousVersion string `json:"previousVersion"`
+	CurrentVersion  string `json:"currentVersion"`
+	PreviousPURL    string `json:"previousPurl"`
+	CurrentPURL     string `json:"currentPurl"`
+	Change          string `json:"change"`
+	LicenseChanged  bool   `json:"licenseChanged"`
+	SupplierChanged bool   `json:"supplierChanged"`
+}
+
+type receipt struct {
+	Path   string `json:"path"`
+	Format string `json:"format"`
+}
+
+type diffDocument struct {
+	Previous receipt             `json:"previous"`
+	Current  receipt             `json:"current"`
+	Summary  map[string]int      `json:"summary"`
+	Added    []component         `json:"added"`
+	Removed  []component         `json:"removed"`
+	Modified []modifiedComponent `json:"modified"`
+}
+
+func TestEntrypoint(t *testing.T) {
+	var Expect = NewWithT(t).Expect
+
+	SetDefaultEventuallyTimeout(5 * time.Second)
+
+	entrypoint, err := gexec.Build("github.com/paketo-buildpacks/github-config/actions/stack/diff-package-receipts/entrypoint")
+	Expect(err).NotTo(HaveOccurred())
+
+	spec.Run(t, "diff-package-receipts", func(t *testing.T, context spec.G, it spec.S) {
+		var (
+			Expect     = NewWithT(t).Expect
+			Eventually = NewWithT(t).Eventually
+
+			tempDir        string
+			outputFilepath string
+		)
+
+		it.Before(func() {
+			tempDir = t.TempDir()
+			outputFilepath = filepath.Join(tempDir, "diff.json")
+		})
+
+		// writeReceipt writes a CycloneDX receipt with the components to the
+		// temporary directory.
+		writeReceipt := func(name string, components string) string {
+			path := filepath.Join(tempDir, name)
+			Expect(os.WriteFile(path, []byte(fmt.Sprintf(`{"bomFormat": "CycloneDX", "components": %s}`, components)), 0600)).To(Succeed())
+
+			return path
+		}
+
+		// run runs the entrypoint with the arguments, expecting it to succeed.
+		run := func(args ...string) {
+			command := exec.Command(entrypoint, args...)
+
+			buffer := gbytes.NewBuffer()
+
+			session, err := gexec.Start(command, buffer, buffer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+		}
+
+		// diff runs the entrypoint on the receipts and returns the document
+		// it wrote.
+		diff := func(previous, current string, args ...string) diffDocument {
+			run(append([]string{
+				"--previous", previous,
+				"--current", current,
+				"--output", outputFilepath,
+			}, args...)...)
+
+			var document diffDocument
+			contents, err := os.ReadFile(outputFilepath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.Unmarshal(contents, &document)).To(Succeed())
+
+			return document
+		}
+
+		context("packages are identified by their PURLs", func() {
+			it("tells apart packages of the same name from different ecosystems", func() {
+				previous := writeReceipt("previous.json", `[
+					{"name": "six", "version": "1.16.0-4", "purl": "pkg:deb/debian/six@1.16.0-4?arch=all&distro=debian-12"},
+					{"name": "six", "version": "1.16.0", "purl": "pkg:pypi/six@1.16.0"}
+				]`)
+				current := writeReceipt("current.json", `[
+					{"name": "six", "version": "1.17.0", "purl": "pkg:pypi/six@1.17.0"},
+					{"name": "six", "version": "1.16.0-4", "purl": "pkg:deb/debian/six@1.16.0-4?arch=all&distro=debian-12"}
+				]`)
+
+				document := diff(previous, current)
+
+				Expect(document.Added).To(BeEmpty())
+				Expect(document.Removed).To(BeEmpty())
+				Expect(document.Modified).To(Equal([]modifiedComponent{
+					{
+						Name:            "six",
+						PreviousVersion: "1.16.0",
+						CurrentVersion:  "1.17.0",
+						PreviousPURL:    "pkg:pypi/six@1.16.0",
+						CurrentPURL:     "pkg:pypi/six@1.17.0",
+						Change:          "unknown",
+					},
+				}))
+			})
+
+			it("tells apart the same deb built for two architectures", func() {
+				previous := writeReceipt("previous.json", `[
+					{"name": "libc6", "version": "2.36-9+deb12u9", "purl": "pkg:deb/debian/libc6@2.36-9%2Bdeb12u9?arch=amd64&distro=debian-12"},
+					{"name": "libc6", "version": "2.36-9+deb12u9", "purl": "pkg:deb/debian/libc6@2.36-9%2Bdeb12u9?arch=i386&distro=debian-12"}
+				]`)
+				current := writeReceipt("current.json", `[
+					{"name": "libc6", "version": "2.36-9+deb12u9", "purl": "pkg:deb/debian/libc6@2.36-9%2Bdeb12u9?arch=i386&distro=debian-12"},
+					{"name": "libc6", "version": "2.36-9+deb12u10", "purl": "pkg:deb/debian/libc6@2.36-9%2Bdeb12u10?arch=amd64&distro=debian-12"}
+				]`)
+
+				document := diff(previous, current)
+
+				Expect(document.Added).To(BeEmpty())
+				Expect(document.Removed).To(BeEmpty())
+				Expect(document.Modified).To(Equal([]modifiedComponent{
+					{
+						Name:            "libc6",
+						PreviousVersion: "2.36-9+deb12u9",
+						CurrentVersion:  "2.36-9+deb12u10",
+						PreviousPURL:    "pkg:deb/debian/libc6@2.36-9%2Bdeb12u9?arch=amd64&distro=debian-12",
+						CurrentPURL:     "pkg:deb/debian/libc6@2.36-9%2Bdeb12u10?arch=amd64&distro=debian-12",
+						Change:          "upgrade",
+					},
+				}))
+			})
+
+			it("pairs a package without a PURL with the package of the same name that has one", func() {
+				previous := writeReceipt("previous.json", `[
+					{"name": "curl", "version": "7.88.1-10+deb12u8", "purl": ""}
+				]`)
+				current := writeReceipt("current.json", `[
+					{"name": "curl", "version": "7.88.1-10+deb12u12", "purl": "pkg:deb/debian/curl@7.88.1-10%2Bdeb12u12?arch=amd64&distro=debian-12"}
+				]`)
+
+				document := diff(previous, current)
+
+				Expect(document.Added).To(BeEmpty())
+				Expect(document.Removed).To(BeEmpty())
+				Expect(document.Modified).To(Equal([]modifiedComponent{
+					{
+						Name:            "curl",
+						PreviousVersion: "7.88.1-10+deb12u8",
+						CurrentVersion:  "7.88.1-10+deb12u12",
+						PreviousPURL:    "",
+						CurrentPURL:     "pkg:deb/debian/curl@7.88.1-10%2Bdeb12u12?arch=amd64&distro=debian-12",
+						Change:          "upgrade",
+					},
+				}))
+			})
+		})
+
+		context("receipts are in other formats", func() {
+			it("reads SPDX and Syft documents, leaving out the packages SPDX documents describe", func() {
+				document := diff("testdata/jammy.spdx.json", "testdata/jammy.syft.json")
+
+				Expect(document.Previous).To(Equal(receipt{Path: "testdata/jammy.spdx.json", Format: "SPDX"}))
+				Expect(document.Current).To(Equal(receipt{Path: "testdata/jammy.syft.json", Format: "Syft"}))
+
+				Expect(document.Added).To(Equal([]component{
+					{
+						Name:    "tzdata",
+						Version: "2025b-0ubuntu0.22.04.1",
+						PURL:    "pkg:deb/ubuntu/tzdata@2025b-0ubuntu0.22.04.1?arch=all&distro=ubuntu-22.04",
+					},
+				}))
+				Expect(document.Removed).To(BeEmpty())
+				Expect(document.Modified).To(Equal([]modifiedComponent{
+					{
+						Name:            "libc6",
+						PreviousVersion: "2.35-0ubuntu3.8",
+						CurrentVersion:  "2.35-0ubuntu3.9",
+						PreviousPURL:    "pkg:deb/ubuntu/libc6@2.35-0ubuntu3.8?arch=amd64&upstream=glibc&distro=ubuntu-22.04",
+						CurrentPURL:     "pkg:deb/ubuntu/libc6@2.35-0ubuntu3.9?arch=amd64&upstream=glibc&distro=ubuntu-22.04",
+						Change:          "upgrade",
+					},
+				}))
+			})
+
+			it("leaves out the packages given in documentDescribes of SPDX documents", func() {
+				previous := filepath.Join(tempDir, "previous.spdx.json")
+				Expect(os.WriteFile(previous, []byte(`{
+					"spdxVersion": "SPDX-2.2",
+					"SPDXID": "SPDXRef-DOCUMENT",
+					"documentDescribes": ["SPDXRef-image"],
+					"packages": [
+						{"SPDXID": "SPDXRef-image", "name": "run-jammy-base", "versionInfo": "sha256:0f1e2d3c"},
+						{"SPDXID": "SPDXRef-Package-deb-tzdata", "name": "tzdata", "versionInfo": "2025b-0ubuntu0.22.04.1"}
+					]
+				}`), 0600)).To(Succeed())
+
+				document := diff(previous, "testdata/jammy.syft.json")
+
+				Expect(document.Removed).To(BeEmpty())
+			})
+
+			it("reads dpkg status files, telling apart packages installed for two architectures and leaving out those only left with config files", func() {
+				document := diff("testdata/previous-status", "testdata/current-status")
+
+				Expect(document.Previous.Format).To(Equal("dpkg status"))
+				Expect(document.Current.Format).To(Equal("dpkg status"))
+
+				Expect(document.Added).To(BeEmpty())
+				Expect(document.Removed).To(Equal([]component{
+					{Name: "zlib1g", Version: "1:1.2.11.dfsg-2ubuntu9.2"},
+				}))
+				Expect(document.Modified).To(Equal([]modifiedComponent{
+					{
+						Name:            "libc6:amd64",
+						PreviousVersion: "2.35-0ubuntu3.8",
+						CurrentVersion:  "2.35-0ubuntu3.9",
+						Change:          "upgrade",
+					},
+				}))
+			})
+		})
+
+		context("the same receipts are diffed twice", func() {
+			it("writes the same JSON document and markdown", func() {
+				for _, name := range []string{"first", "second"} {
+					run(
+						"--previous", "testdata/jammy.spdx.json",
+						"--current", "testdata/jammy.syft.json",
+						"--output", filepath.Join(tempDir, name+".json"),
+						"--markdown-output", filepath.Join(tempDir, name+".md"),
+					)
+				}
+
+				for _, extension := range []string{".json", ".md"} {
+					first, err := os.ReadFile(filepath.Join(tempDir, "first"+extension))
+					Expect(err).NotTo(HaveOccurred())
+
+					second, err := os.ReadFile(filepath.Join(tempDir, "second"+extension))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(second).To(Equal(first))
+				}
+			})
+		})
+
+		context("nothing changed between the receipts", func() {
+			it("writes empty lists rather than null", func() {
+				run(
+					"--previous", "testdata/previous-status",
+					"--current", "testdata/previous-status",
+					"--output", outputFilepath,
+					"--added-diff-file", filepath.Join(tempDir, "added.json"),
+					"--removed-diff-file", filepath.Join(tempDir, "removed.json"),
+					"--modified-diff-file", filepath.Join(tempDir, "modified.json"),
+				)
+
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`"added": [],`))
+				Expect(string(contents)).To(ContainSubstring(`"removed": [],`))
+				Expect(string(contents)).To(ContainSubstring(`"modified": []`))
+				Expect(string(contents)).NotTo(ContainSubstring("null"))
+
+				for _, name := range []string{"added.json", "removed.json", "modified.json"} {
+					contents, err := os.ReadFile(filepath.Join(tempDir, name))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(contents)).To(Equal("[]\n"))
+				}
+			})
+		})
+
+		context("the versions of packages changed", func() {
+			it("compares the versions of Debian packages", func() {
+				previous := writeReceipt("previous.json", `[
+					{"name": "curl", "version": "7.88.1-10+deb12u12", "purl": "pkg:deb/debian/curl@7.88.1-10%2Bdeb12u12?arch=amd64"},
+					{"name": "tzdata", "version": "2025b-0+deb12u1", "purl": "pkg:deb/debian/tzdata@2025b-0%2Bdeb12u1?arch=all"},
+					{"name": "libgcc-s1", "version": "12.2.0-14", "purl": "pkg:deb/debian/libgcc-s1@12.2.0-14?arch=amd64&upstream=gcc-12"}
+				]`)
+				current := writeReceipt("current.json", `[
+					{"name": "curl", "version": "7.88.1-10+deb12u14", "purl": "pkg:deb/debian/curl@7.88.1-10%2Bdeb12u14?arch=amd64"},
+					{"name": "tzdata", "version": "2025a-0+deb12u1", "purl": "pkg:deb/debian/tzdata@2025a-0%2Bdeb12u1?arch=all"},
+					{"name": "libgcc-s1", "version": "12.2.0-14", "purl": "pkg:deb/debian/libgcc-s1@12.2.0-14?arch=amd64&upstream=gcc-12%4012.2.0-14%2Bdeb12u1"}
+				]`)
+
+				document := diff(previous, current)
+
+				Expect(document.Modified).To(HaveLen(3))
+				Expect(document.Modified[0].Name).To(Equal("curl"))
+				Expect(document.Modified[0].Change).To(Equal("upgrade"))
+				Expect(document.Modified[1].Name).To(Equal("libgcc-s1"))
+				Expect(document.Modified[1].Change).To(Equal("rebuild"))
+				Expect(document.Modified[2].Name).To(Equal("tzdata"))
+				Expect(document.Modified[2].Change).To(Equal("downgrade"))
+			})
+
+			it("does not compare the versions of packages of other ecosystems", func() {
+				previous := writeReceipt("previous.json", `[
+					{"name": "left-pad", "version": "2.0.0", "purl": "pkg:npm/left-pad@2.0.0"},
+					{"name": "requests", "version": "1.0", "purl": "pkg:pypi/requests@1.0"}
+				]`)
+				current := writeReceipt("current.json", `[
+					{"name": "left-pad", "version": "2.0.0-beta.1", "purl": "pkg:npm/left-pad@2.0.0-beta.1"},
+					{"name": "requests", "version": "1.0rc1", "purl": "pkg:pypi/requests@1.0rc1"}
+				]`)
+
+				document := diff(previous, current)
+
+				Expect(document.Modified).To(HaveLen(2))
+				Expect(document.Modified[0].Name).To(Equal("left-pad"))
+				Expect(document.Modified[0].Change).To(Equal("unknown"))
+				Expect(document.Modified[1].Name).To(Equal("requests"))
+				Expect(document.Modified[1].Change).To(Equal("unknown"))
+			})
+
+			it("classifies packages of any ecosystem whose PURL changed at the same version as rebuilds", func() {
+				previous := writeReceipt("previous.json", `[
+					{"name": "left-pad", "version": "1.3.0", "purl": "pkg:npm/left-pad@1.3.0"}
+				]`)
+				current := writeReceipt("current.json", `[
+					{"name": "left-pad", "version": "1.3.0", "purl": "pkg:npm/left-pad@1.3.0?repository_url=https://registry.example.com"}
+				]`)
+
+				document := diff(previous, current)
+
+				Expect(document.Modified).To(HaveLen(1))
+				Expect(document.Modified[0].Change).To(Equal("rebuild"))
+			})
+		})
+
+		context("a package was downgraded", func() {
+			var previous, current string
+
+			it.Before(func() {
+				previous = writeReceipt("previous.json", `[
+					{"name": "tzdata", "version": "2025b-0+deb12u1", "purl": "pkg:deb/debian/tzdata@2025b-0%2Bdeb12u1?arch=all"}
+				]`)
+				current = writeReceipt("current.json", `[
+					{"name": "tzdata", "version": "2025a-0+deb12u1", "purl": "pkg:deb/debian/tzdata@2025a-0%2Bdeb12u1?arch=all"}
+				]`)
+			})
+
+			it("fails with --fail-on-downgrade", func() {
+				command := exec.Command(entrypoint,
+					"--previous", previous,
+					"--current", current,
+					"--output", outputFilepath,
+					"--fail-on-downgrade",
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(buffer).To(gbytes.Say(`Found 1 downgraded packages: tzdata 2025b-0\+deb12u1 => 2025a-0\+deb12u1`))
+			})
+
+			it("succeeds without --fail-on-downgrade", func() {
+				document := diff(previous, current)
+
+				Expect(document.Modified).To(HaveLen(1))
+				Expect(document.Modified[0].Change).To(Equal("downgrade"))
+			})
+		})
+
+		context("only the metadata of packages changed", func() {
+			it("classifies checksum, license and supplier changes and counts every change", func() {
+				previous := writeReceipt("previous.json", `[
+					{"name": "curl", "version": "7.81.0-1ubuntu1.20", "purl": "pkg:deb/ubuntu/curl@7.81.0-1ubuntu1.20?arch=amd64",
+						"hashes": [{"alg": "SHA-256", "content": "0a1b2c"}]},
+					{"name": "libzstd1", "version": "1.4.8+dfsg-3build1", "purl": "pkg:deb/ubuntu/libzstd1@1.4.8%2Bdfsg-3build1?arch=amd64",
+						"licenses": [{"license": {"id": "BSD-3-Clause"}}]},
+					{"name": "tzdata", "version": "2025b-0ubuntu0.22.04.1", "purl": "pkg:deb/ubuntu/tzdata@2025b-0ubuntu0.22.04.1?arch=all",
+						"supplier": {"name": "Ubuntu Developers"}},
+					{"name": "left-pad", "version": "1.3.0", "purl": "pkg:npm/left-pad@1.3.0"}
+				]`)
+				current := writeReceipt("current.json", `[
+					{"name": "curl", "version": "7.81.0-1ubuntu1.20", "purl": "pkg:deb/ubuntu/curl@7.81.0-1ubuntu1.20?arch=amd64",
+						"hashes": [{"alg": "SHA-256", "content": "3d4e5f"}]},
+					{"name": "libzstd1", "version": "1.4.8+dfsg-3build1", "purl": "pkg:deb/ubuntu/libzstd1@1.4.8%2Bdfsg-3build1?arch=amd64",
+						"licenses": [{"license": {"id": "BSD-3-Clause"}}, {"license": {"id": "GPL-2.0-only"}}]},
+					{"name": "tzdata", "version": "2025b-0ubuntu0.22.04.1", "purl": "pkg:deb/ubuntu/tzdata@2025b-0ubuntu0.22.04.1?arch=all",
+						"supplier": {"name": "Canonical"}},
+					{"name": "left-pad", "version": "1.3.1", "purl": "pkg:npm/left-pad@1.3.1"}
+				]`)
+
+				document := diff(previous, current)
+
+				Expect(document.Modified).To(HaveLen(4))
+				Expect(document.Modified[0].Name).To(Equal("curl"))
+				Expect(document.Modified[0].Change).To(Equal("checksum"))
+				Expect(document.Modified[1].Name).To(Equal("left-pad"))
+				Expect(document.Modified[1].Change).To(Equal("unknown"))
+				Expect(document.Modified[2].Name).To(Equal("libzstd1"))
+				Expect(document.Modified[2].Change).To(Equal("metadata"))
+				Expect(document.Modified[2].LicenseChanged).To(BeTrue())
+				Expect(document.Modified[3].Name).To(Equal("tzdata"))
+				Expect(document.Modified[3].Change).To(Equal("metadata"))
+				Expect(document.Modified[3].SupplierChanged).To(BeTrue())
+
+				Expect(document.Summary).To(Equal(map[string]int{
+					"added":           0,
+					"removed":         0,
+					"modified":        4,
+					"upgrades":        0,
+					"downgrades":      0,
+					"rebuilds":        0,
+					"checksumChanges": 1,
+					"metadataChanges": 2,
+					"unknownChanges":  1,
+					"licenseChanges":  1,
+					"supplierChanges": 1,
+				}))
+			})
+
+			it("classifies a checksum change along with a license change as a metadata change", func() {
+				previous := writeReceipt("previous.json", `[
+					{"name": "curl", "version": "7.81.0-1ubuntu1.20", "purl": "pkg:deb/ubuntu/curl@7.81.0-1ubuntu1.20?arch=amd64",
+						"hashes": [{"alg": "SHA-256", "content": "0a1b2c"}],
+						"licenses": [{"license": {"id": "curl"}}]}
+				]`)
+				current := writeReceipt("current.json", `[
+					{"name": "curl", "version": "7.81.0-1ubuntu1.20", "purl": "pkg:deb/ubuntu/curl@7.81.0-1ubuntu1.20?arch=amd64",
+						"hashes": [{"alg": "SHA-256", "content": "3d4e5f"}],
+						"licenses": [{"license": {"id": "MIT"}}]}
+				]`)
+
+				document := diff(previous, current)
+
+				Expect(document.Modified).To(HaveLen(1))
+				Expect(document.Modified[0].Change).To(Equal("metadata"))
+				Expect(document.Modified[0].LicenseChanged).To(BeTrue())
+				Expect(document.Summary["checksumChanges"]).To(Equal(0))
+				Expect(document.Summary["metadataChanges"]).To(Equal(1))
+			})
+		})
+
+		context("licenses are denied", func() {
+			it("allows packages that may be used under a license that is not denied", func() {
+				previous := writeReceipt("previous.json", `[]`)
+				current := writeReceipt("current.json", `[
+					{"name": "ruby-json", "version": "2.6.1+dfsg-1", "purl": "pkg:deb/debian/ruby-json@2.6.1%2Bdfsg-1",
+						"licenses": [{"expression": "Ruby OR GPL-3.0-only"}]},
+					{"name": "openjdk-17-jre-headless", "version": "17.0.16+8-1~deb12u1", "purl": "pkg:deb/debian/openjdk-17-jre-headless@17.0.16%2B8-1~deb12u1",
+						"licenses": [{"expression": "GPL-2.0-only WITH Classpath-exception-2.0"}]}
+				]`)
+
+				document := diff(previous, current, "--deny-licenses", "GPL-3.0-only,Classpath-exception-2.0")
+
+				Expect(document.Added).To(HaveLen(2))
+			})
+
+			it("fails when every license a package may be used under has a denied one", func() {
+				previous := writeReceipt("previous.json", `[]`)
+				current := writeReceipt("current.json", `[
+					{"name": "readline-common", "version": "8.2-1.3", "purl": "pkg:deb/debian/readline-common@8.2-1.3",
+						"licenses": [{"expression": "GPL-3.0-only OR AGPL-3.0-only"}]},
+					{"name": "libgmp10", "version": "2:6.2.1+dfsg1-1.1", "purl": "pkg:deb/debian/libgmp10@2:6.2.1%2Bdfsg1-1.1",
+						"licenses": [{"expression": "(LGPL-3.0-or-later OR GPL-2.0-or-later) AND GPL-3.0-only"}]},
+					{"name": "zlib1g", "version": "1:1.2.13.dfsg-1", "purl": "pkg:deb/debian/zlib1g@1:1.2.13.dfsg-1",
+						"licenses": [{"expression": "Zlib"}]}
+				]`)
+
+				command := exec.Command(entrypoint,
+					"--previous", previous,
+					"--current", current,
+					"--output", outputFilepath,
+					"--deny-licenses", "gpl-3.0-only,AGPL-3.0-only",
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(buffer).To(gbytes.Say(`Found 2 packages with denied licenses: libgmp10 \(GPL-3.0-only\), readline-common \(GPL-3.0-only, AGPL-3.0-only\)`))
+			})
+		})
+	})
+}
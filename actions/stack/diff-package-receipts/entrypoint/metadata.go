@@ -0,0 +1,216 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// CycloneDXLicenseChoice is either a license or an SPDX license expression,
+// see https://cyclonedx.org/docs/1.5/json/#components_items_licenses.
+type CycloneDXLicenseChoice struct {
+	License    *CycloneDXLicense `json:"license,omitempty"`
+	Expression string            `json:"expression,omitempty"`
+}
+
+type CycloneDXLicense struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+type CycloneDXOrganization struct {
+	Name string   `json:"name,omitempty"`
+	URL  []string `json:"url,omitempty"`
+}
+
+type CycloneDXHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+// licenseExpression returns the licenses of the component as a single
+// expression, so that the licenses of components can be compared whichever
+// way the receipts list them. Receipts that list several licenses mean all of
+// them apply.
+func licenseExpression(component CycloneDXComponent) string {
+	var licenses []string
+	for _, choice := range component.Licenses {
+		var license string
+		switch {
+		case choice.Expression != "":
+			license = choice.Expression
+		case choice.License != nil && choice.License.ID != "":
+			license = choice.License.ID
+		case choice.License != nil:
+			license = choice.License.Name
+		}
+
+		if license == "" {
+			continue
+		}
+		if len(component.Licenses) > 1 && strings.Contains(license, " ") {
+			license = "(" + license + ")"
+		}
+		licenses = append(licenses, license)
+	}
+	sort.Strings(licenses)
+
+	return strings.Join(licenses, " AND ")
+}
+
+func supplierName(component CycloneDXComponent) string {
+	if component.Supplier == nil {
+		return ""
+	}
+
+	return component.Supplier.Name
+}
+
+// licenseChanged tells whether the licenses of a package changed. A receipt
+// that does not give the licenses of a package, such as a dpkg status file,
+// does not say they changed.
+func licenseChanged(previous, current CycloneDXComponent) bool {
+	previousLicense, currentLicense := licenseExpression(previous), licenseExpression(current)
+
+	return previousLicense != "" && currentLicense != "" && previousLicense != currentLicense
+}
+
+func supplierChanged(previous, current CycloneDXComponent) bool {
+	previousSupplier, currentSupplier := supplierName(previous), supplierName(current)
+
+	return previousSupplier != "" && currentSupplier != "" && previousSupplier != currentSupplier
+}
+
+// hashesChanged tells whether the package has a different checksum for any
+// algorithm both receipts give one for.
+func hashesChanged(previous, current CycloneDXComponent) bool {
+	previousHashes := map[string]string{}
+	for _, hash := range previous.Hashes {
+		previousHashes[hashAlgorithm(hash.Algorithm)] = strings.ToLower(hash.Content)
+	}
+
+	for _, hash := range current.Hashes {
+		content, ok := previousHashes[hashAlgorithm(hash.Algorithm)]
+		if ok && content != strings.ToLower(hash.Content) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hashAlgorithm normalizes the names of checksum algorithms, which are given
+// as SHA-256 by CycloneDX and as SHA256 by SPDX.
+func hashAlgorithm(algorithm string) string {
+	return strings.ToUpper(strings.ReplaceAll(algorithm, "-", ""))
+}
+
+// parseLicenseList parses the comma separated list of license identifiers
+// given by --deny-licenses.
+func parseLicenseList(value string) map[string]bool {
+	licenses := map[string]bool{}
+	for _, license := range strings.Split(value, ",") {
+		license = strings.TrimSpace(license)
+		if license != "" {
+			licenses[strings.ToLower(license)] = true
+		}
+	}
+
+	return licenses
+}
+
+// deniedLicenses returns the denied licenses the SPDX license expression
+// cannot do without: those of both sides of an AND, but those of an OR only
+// when each of its sides has some, as the package may be used under the side
+// that has none. License identifiers are compared ignoring case as SPDX does,
+// and the exceptions given with WITH are not licenses of their own.
+func deniedLicenses(expression string, denied map[string]bool) []string {
+	tokens := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expression))
+	parser := &licenseExpressionParser{tokens: tokens, denied: denied}
+
+	var licenses []string
+	seen := map[string]bool{}
+	for parser.position < len(parser.tokens) {
+		for _, license := range parser.parseOr() {
+			if !seen[license] {
+				seen[license] = true
+				licenses = append(licenses, license)
+			}
+		}
+
+		// an unbalanced closing parenthesis or a missing operator ends an
+		// expression early, the rest of which is read on its own
+		if parser.peek() == ")" {
+			parser.position++
+		}
+	}
+
+	return licenses
+}
+
+// licenseExpressionParser reads an SPDX license expression, in which WITH
+// binds tighter than AND, which binds tighter than OR, see
+// https://spdx.github.io/spdx-spec/v2.3/SPDX-license-expressions/.
+type licenseExpressionParser struct {
+	tokens   []string
+	position int
+	denied   map[string]bool
+}
+
+func (p *licenseExpressionParser) peek() string {
+	if p.position >= len(p.tokens) {
+		return ""
+	}
+
+	return p.tokens[p.position]
+}
+
+func (p *licenseExpressionParser) parseOr() []string {
+	licenses := p.parseAnd()
+	for strings.EqualFold(p.peek(), "OR") {
+		p.position++
+		alternative := p.parseAnd()
+		if len(licenses) == 0 || len(alternative) == 0 {
+			licenses = nil
+			continue
+		}
+		licenses = append(licenses, alternative...)
+	}
+
+	return licenses
+}
+
+func (p *licenseExpressionParser) parseAnd() []string {
+	licenses := p.parseLicense()
+	for strings.EqualFold(p.peek(), "AND") {
+		p.position++
+		licenses = append(licenses, p.parseLicense()...)
+	}
+
+	return licenses
+}
+
+func (p *licenseExpressionParser) parseLicense() []string {
+	token := p.peek()
+	switch token {
+	case "", ")":
+		return nil
+	case "(":
+		p.position++
+		licenses := p.parseOr()
+		if p.peek() == ")" {
+			p.position++
+		}
+		return licenses
+	}
+	p.position++
+
+	if strings.EqualFold(p.peek(), "WITH") {
+		p.position += 2
+	}
+
+	if p.denied[strings.ToLower(token)] {
+		return []string{token}
+	}
+
+	return nil
+}
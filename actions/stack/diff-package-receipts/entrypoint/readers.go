@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// receiptReader reads the components of a package receipt in one format into
+// the CycloneDX component model, so that receipts of any format are diffed
+// the same way.
+type receiptReader interface {
+	format() string
+	detect(content []byte) bool
+	read(content []byte) ([]CycloneDXComponent, error)
+}
+
+// receiptReaders are tried in order until one detects the format of a
+// receipt.
+var receiptReaders = []receiptReader{
+	cycloneDXReader{},
+	spdxReader{},
+	syftReader{},
+	dpkgStatusReader{},
+}
+
+func readReceipt(content []byte) ([]CycloneDXComponent, string, error) {
+	for _, reader := range receiptReaders {
+		if reader.detect(content) {
+			components, err := reader.read(content)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to read %s receipt: %w", reader.format(), err)
+			}
+
+			return components, reader.format(), nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("receipt is not a CycloneDX, SPDX or Syft JSON document, or a dpkg status file")
+}
+
+// jsonFields returns the top level fields of a JSON object, or nil when the
+// content is not one.
+func jsonFields(content []byte) map[string]json.RawMessage {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(content, &fields)
+	if err != nil {
+		return nil
+	}
+
+	return fields
+}
+
+type cycloneDXReader struct{}
+
+func (cycloneDXReader) format() string {
+	return "CycloneDX"
+}
+
+func (cycloneDXReader) detect(content []byte) bool {
+	fields := jsonFields(content)
+	_, hasComponents := fields["components"]
+	_, hasBOMFormat := fields["bomFormat"]
+
+	return hasComponents || hasBOMFormat
+}
+
+func (cycloneDXReader) read(content []byte) ([]CycloneDXComponent, error) {
+	var c CycloneDXPackageList
+	err := json.Unmarshal(content, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Components, nil
+}
+
+// spdxReader reads SPDX 2.3 JSON documents, see
+// https://spdx.github.io/spdx-spec/v2.3/package-information/.
+type spdxReader struct{}
+
+func (spdxReader) format() string {
+	return "SPDX"
+}
+
+func (spdxReader) detect(content []byte) bool {
+	_, ok := jsonFields(content)["spdxVersion"]
+	return ok
+}
+
+func (spdxReader) read(content []byte) ([]CycloneDXComponent, error) {
+	var document struct {
+		SPDXID            string   `json:"SPDXID"`
+		DocumentDescribes []string `json:"documentDescribes"`
+		Relationships     []struct {
+			SPDXElementID      string `json:"spdxElementId"`
+			RelationshipType   string `json:"relationshipType"`
+			RelatedSPDXElement string `json:"relatedSpdxElement"`
+		} `json:"relationships"`
+		Packages []struct {
+			SPDXID           string `json:"SPDXID"`
+			Name             string `json:"name"`
+			VersionInfo      string `json:"versionInfo"`
+			Supplier         string `json:"supplier"`
+			LicenseConcluded string `json:"licenseConcluded"`
+			LicenseDeclared  string `json:"licenseDeclared"`
+			Checksums        []struct {
+				Algorithm     string `json:"algorithm"`
+				ChecksumValue string `json:"checksumValue"`
+			} `json:"checksums"`
+			ExternalRefs []struct {
+				ReferenceCategory string `json:"referenceCategory"`
+				ReferenceType     string `json:"referenceType"`
+				ReferenceLocator  string `json:"referenceLocator"`
+			} `json:"externalRefs"`
+		} `json:"packages"`
+	}
+	err := json.Unmarshal(content, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	// the packages the document describes, such as the stack image, are not
+	// packages of the receipt. Newer documents give them as DESCRIBES
+	// relationships of the document rather than in documentDescribes.
+	documentID := document.SPDXID
+	if documentID == "" {
+		documentID = "SPDXRef-DOCUMENT"
+	}
+
+	described := map[string]bool{}
+	for _, id := range document.DocumentDescribes {
+		described[id] = true
+	}
+	for _, relationship := range document.Relationships {
+		switch {
+		case relationship.RelationshipType == "DESCRIBES" && relationship.SPDXElementID == documentID:
+			described[relationship.RelatedSPDXElement] = true
+		case relationship.RelationshipType == "DESCRIBED_BY" && relationship.RelatedSPDXElement == documentID:
+			described[relationship.SPDXElementID] = true
+		}
+	}
+
+	var components []CycloneDXComponent
+	for _, pkg := range document.Packages {
+		if described[pkg.SPDXID] {
+			continue
+		}
+
+		component := CycloneDXComponent{
+			Name:    pkg.Name,
+			Version: pkg.VersionInfo,
+		}
+		for _, ref := range pkg.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				component.PURL = ref.ReferenceLocator
+				break
+			}
+		}
+
+		// the concluded license is the one the author of the document found
+		// to apply, which takes precedence over the one the package declares
+		for _, license := range []string{pkg.LicenseConcluded, pkg.LicenseDeclared} {
+			if spdxValue(license) != "" {
+				component.Licenses = []CycloneDXLicenseChoice{{Expression: license}}
+				break
+			}
+		}
+
+		if supplier := spdxValue(pkg.Supplier); supplier != "" {
+			// suppliers are given as Organization: name or Person: name
+			if _, name, ok := strings.Cut(supplier, ":"); ok {
+				supplier = strings.TrimSpace(name)
+			}
+			component.Supplier = &CycloneDXOrganization{Name: supplier}
+		}
+
+		for _, checksum := range pkg.Checksums {
+			component.Hashes = append(component.Hashes, CycloneDXHash{
+				Algorithm: checksum.Algorithm,
+				Content:   checksum.ChecksumValue,
+			})
+		}
+
+		components = append(components, component)
+	}
+
+	return components, nil
+}
+
+// spdxValue returns the value of an SPDX field, or nothing when it is
+// NOASSERTION or NONE, which say the value is not known.
+func spdxValue(value string) string {
+	if value == "NOASSERTION" || value == "NONE" {
+		return ""
+	}
+
+	return value
+}
+
+// syftReader reads the native JSON documents of Syft, see
+// https://github.com/anchore/syft/tree/main/schema/json.
+type syftReader struct{}
+
+func (syftReader) format() string {
+	return "Syft"
+}
+
+func (syftReader) detect(content []byte) bool {
+	fields := jsonFields(content)
+	_, hasArtifacts := fields["artifacts"]
+	_, hasSchema := fields["schema"]
+
+	return hasArtifacts && hasSchema
+}
+
+func (syftReader) read(content []byte) ([]CycloneDXComponent, error) {
+	var document struct {
+		Artifacts []struct {
+			Name     string            `json:"name"`
+			Version  string            `json:"version"`
+			PURL     string            `json:"purl"`
+			Licenses []json.RawMessage `json:"licenses"`
+		} `json:"artifacts"`
+	}
+	err := json.Unmarshal(content, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	var components []CycloneDXComponent
+	for _, artifact := range document.Artifacts {
+		components = append(components, CycloneDXComponent{
+			Name:     artifact.Name,
+			Version:  artifact.Version,
+			PURL:     artifact.PURL,
+			Licenses: syftLicenses(artifact.Licenses),
+		})
+	}
+
+	return components, nil
+}
+
+// syftLicenses reads the licenses of an artifact, which older schemas give as
+// strings and newer ones as objects with an SPDX expression when the license
+// is known to SPDX.
+func syftLicenses(licenses []json.RawMessage) []CycloneDXLicenseChoice {
+	var choices []CycloneDXLicenseChoice
+	for _, raw := range licenses {
+		var name string
+		if json.Unmarshal(raw, &name) == nil {
+			if name != "" {
+				choices = append(choices, CycloneDXLicenseChoice{License: &CycloneDXLicense{Name: name}})
+			}
+			continue
+		}
+
+		var license struct {
+			Value          string `json:"value"`
+			SPDXExpression string `json:"spdxExpression"`
+		}
+		if json.Unmarshal(raw, &license) != nil {
+			continue
+		}
+
+		switch {
+		case license.SPDXExpression != "":
+			choices = append(choices, CycloneDXLicenseChoice{Expression: license.SPDXExpression})
+		case license.Value != "":
+			choices = append(choices, CycloneDXLicenseChoice{License: &CycloneDXLicense{Name: license.Value}})
+		}
+	}
+
+	return choices
+}
+
+// dpkgStatusReader reads dpkg status files, such as /var/lib/dpkg/status,
+// see https://man7.org/linux/man-pages/man1/dpkg-query.1.html. The file does
+// not say which distro the packages are from, so they are given no PURL.
+type dpkgStatusReader struct{}
+
+func (dpkgStatusReader) format() string {
+	return "dpkg status"
+}
+
+func (dpkgStatusReader) detect(content []byte) bool {
+	return bytes.HasPrefix(content, []byte("Package: ")) || bytes.Contains(content, []byte("\nPackage: "))
+}
+
+func (dpkgStatusReader) read(content []byte) ([]CycloneDXComponent, error) {
+	type stanza struct {
+		name, version, architecture, status string
+	}
+
+	var stanzas []stanza
+	var current stanza
+	add := func() {
+		// packages that were removed but left their config files behind are
+		// not installed
+		if current.name != "" && strings.HasSuffix(current.status, " installed") {
+			stanzas = append(stanzas, current)
+		}
+		current = stanza{}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			add()
+			continue
+		}
+
+		field, value, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(line, " ") {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+		switch field {
+		case "Package":
+			current.name = value
+		case "Version":
+			current.version = value
+		case "Architecture":
+			current.architecture = value
+		case "Status":
+			current.status = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	add()
+
+	// packages installed for more than one architecture are told apart by
+	// their architecture, as dpkg does
+	count := map[string]int{}
+	for _, s := range stanzas {
+		count[s.name]++
+	}
+
+	var components []CycloneDXComponent
+	for _, s := range stanzas {
+		name := s.name
+		if count[s.name] > 1 {
+			name = fmt.Sprintf("%s:%s", s.name, s.architecture)
+		}
+
+		components = append(components, CycloneDXComponent{
+			Name:         name,
+			Version:      s.version,
+			architecture: s.architecture,
+		})
+	}
+
+	return components, nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ReceiptSummary describes a receipt that was diffed, so that a diff can be
+// traced back to the receipts it was made from.
+type ReceiptSummary struct {
+	Path   string `json:"path"`
+	Format string `json:"format"`
+	SHA256 string `json:"sha256"`
+}
+
+// DiffSummary counts the packages of a diff. Each modified package is counted
+// by its change, from upgrades to unknown changes, which add up to the
+// modified packages. Packages whose license or supplier changed are counted
+// along with their change.
+type DiffSummary struct {
+	Added      int `json:"added"`
+	Removed    int `json:"removed"`
+	Modified   int `json:"modified"`
+	Upgrades   int `json:"upgrades"`
+	Downgrades int `json:"downgrades"`
+	Rebuilds   int `json:"rebuilds"`
+
+	ChecksumChanges int `json:"checksumChanges"`
+	MetadataChanges int `json:"metadataChanges"`
+	UnknownChanges  int `json:"unknownChanges"`
+	LicenseChanges  int `json:"licenseChanges"`
+	SupplierChanges int `json:"supplierChanges"`
+}
+
+// DiffDocument is the diff of two receipts as a single JSON document. Its
+// lists are sorted, so that the same receipts always produce the same
+// document.
+type DiffDocument struct {
+	Previous ReceiptSummary               `json:"previous"`
+	Current  ReceiptSummary               `json:"current"`
+	Summary  DiffSummary                  `json:"summary"`
+	Added    []CycloneDXComponent         `json:"added"`
+	Removed  []CycloneDXComponent         `json:"removed"`
+	Modified []ModifiedCycloneDXComponent `json:"modified"`
+}
+
+func newDiffDocument(previous, current ReceiptSummary, added, removed []CycloneDXComponent, modified []ModifiedCycloneDXComponent) DiffDocument {
+	document := DiffDocument{
+		Previous: previous,
+		Current:  current,
+		Summary: DiffSummary{
+			Added:    len(added),
+			Removed:  len(removed),
+			Modified: len(modified),
+		},
+		Added:    added,
+		Removed:  removed,
+		Modified: modified,
+	}
+
+	for _, pkg := range modified {
+		switch pkg.Change {
+		case ChangeUpgrade:
+			document.Summary.Upgrades++
+		case ChangeDowngrade:
+			document.Summary.Downgrades++
+		case ChangeRebuild:
+			document.Summary.Rebuilds++
+		case ChangeChecksum:
+			document.Summary.ChecksumChanges++
+		case ChangeMetadata:
+			document.Summary.MetadataChanges++
+		case ChangeUnknown:
+			document.Summary.UnknownChanges++
+		}
+
+		if pkg.LicenseChanged {
+			document.Summary.LicenseChanges++
+		}
+		if pkg.SupplierChanged {
+			document.Summary.SupplierChanges++
+		}
+	}
+
+	return document
+}
+
+// sortComponents sorts components by name, then PURL, then version, and
+// returns an empty list rather than nil, so that it is written as [].
+func sortComponents(components []CycloneDXComponent) []CycloneDXComponent {
+	sort.SliceStable(components, func(i, j int) bool {
+		a, b := components[i], components[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.PURL != b.PURL {
+			return a.PURL < b.PURL
+		}
+		return a.Version < b.Version
+	})
+
+	if components == nil {
+		return []CycloneDXComponent{}
+	}
+
+	return components
+}
+
+func sortModifiedComponents(components []ModifiedCycloneDXComponent) []ModifiedCycloneDXComponent {
+	sort.SliceStable(components, func(i, j int) bool {
+		a, b := components[i], components[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.CurrentPURL != b.CurrentPURL {
+			return a.CurrentPURL < b.CurrentPURL
+		}
+		return a.PreviousPURL < b.PreviousPURL
+	})
+
+	if components == nil {
+		return []ModifiedCycloneDXComponent{}
+	}
+
+	return components
+}
+
+// writeJSONDocument writes the document indented and without escaping the
+// ampersands of PURLs, so that it reads well in reviews.
+func writeJSONDocument(path string, document DiffDocument) error {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	err := encoder.Encode(document)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buffer.Bytes(), 0666)
+}
+
+// renderMarkdown renders the document as markdown, such as for a pull
+// request or release notes.
+func renderMarkdown(document DiffDocument) string {
+	var b strings.Builder
+
+	b.WriteString("## Package changes\n\n")
+	fmt.Fprintf(&b, "Comparing `%s` (%s) with `%s` (%s).\n\n",
+		document.Previous.Path, document.Previous.Format,
+		document.Current.Path, document.Current.Format,
+	)
+
+	b.WriteString("| Added | Removed | Modified | Upgrades | Downgrades | Rebuilds | Checksum changes | Metadata changes | Unknown changes | License changes | Supplier changes |\n")
+	b.WriteString("|---|---|---|---|---|---|---|---|---|---|---|\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d | %d | %d | %d | %d | %d | %d | %d |\n",
+		document.Summary.Added,
+		document.Summary.Removed,
+		document.Summary.Modified,
+		document.Summary.Upgrades,
+		document.Summary.Downgrades,
+		document.Summary.Rebuilds,
+		document.Summary.ChecksumChanges,
+		document.Summary.MetadataChanges,
+		document.Summary.UnknownChanges,
+		document.Summary.LicenseChanges,
+		document.Summary.SupplierChanges,
+	)
+
+	writeComponents := func(title string, components []CycloneDXComponent) {
+		fmt.Fprintf(&b, "\n### %s (%d)\n\n", title, len(components))
+		if len(components) == 0 {
+			b.WriteString("None\n")
+			return
+		}
+
+		b.WriteString("| Package | Version | License | PURL |\n")
+		b.WriteString("|---|---|---|---|\n")
+		for _, pkg := range components {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+				markdownCell(pkg.Name),
+				markdownCell(pkg.Version),
+				markdownCell(licenseExpression(pkg)),
+				markdownCell(pkg.PURL),
+			)
+		}
+	}
+
+	writeComponents("Added", document.Added)
+	writeComponents("Removed", document.Removed)
+
+	fmt.Fprintf(&b, "\n### Modified (%d)\n\n", len(document.Modified))
+	if len(document.Modified) == 0 {
+		b.WriteString("None\n")
+	} else {
+		b.WriteString("| Package | Previous version | Current version | Change |\n")
+		b.WriteString("|---|---|---|---|\n")
+		for _, pkg := range document.Modified {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+				markdownCell(pkg.Name),
+				markdownCell(pkg.PreviousVersion),
+				markdownCell(pkg.CurrentVersion),
+				pkg.Change,
+			)
+		}
+	}
+
+	var licenseChanges, supplierChanges []ModifiedCycloneDXComponent
+	for _, pkg := range document.Modified {
+		if pkg.LicenseChanged {
+			licenseChanges = append(licenseChanges, pkg)
+		}
+		if pkg.SupplierChanged {
+			supplierChanges = append(supplierChanges, pkg)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n### License changes (%d)\n\n", len(licenseChanges))
+	if len(licenseChanges) == 0 {
+		b.WriteString("None\n")
+	} else {
+		b.WriteString("| Package | Previous license | Current license |\n")
+		b.WriteString("|---|---|---|\n")
+		for _, pkg := range licenseChanges {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", markdownCell(pkg.Name), markdownCell(pkg.PreviousLicense), markdownCell(pkg.CurrentLicense))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n### Supplier changes (%d)\n\n", len(supplierChanges))
+	if len(supplierChanges) == 0 {
+		b.WriteString("None\n")
+	} else {
+		b.WriteString("| Package | Previous supplier | Current supplier |\n")
+		b.WriteString("|---|---|---|\n")
+		for _, pkg := range supplierChanges {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", markdownCell(pkg.Name), markdownCell(pkg.PreviousSupplier), markdownCell(pkg.CurrentSupplier))
+		}
+	}
+
+	return b.String()
+}
+
+// markdownCell formats a value for a table cell, as code so that characters
+// such as ~ and _ in versions are not taken as formatting.
+func markdownCell(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return "`" + strings.ReplaceAll(value, "|", "\\|") + "`"
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// usnCache keeps pages of notices on disk, keyed by distro and offset, along
+// with the advisory lists and tracker of the Debian Security Tracker, so that
+// runs within the TTL do not request them again and later runs can
+// revalidate them with conditional requests.
+type usnCache struct {
+	dir     string
+	ttl     time.Duration
+	offline bool
+}
+
+type cacheEntry struct {
+	URL          string          `json:"url"`
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"last_modified,omitempty"`
+	FetchedAt    time.Time       `json:"fetched_at"`
+	Body         json.RawMessage `json:"body,omitempty"`
+
+	// Text holds bodies that are not JSON, such as the advisory lists of the
+	// Debian Security Tracker.
+	Text string `json:"text,omitempty"`
+}
+
+func newCacheEntry(url string, resp *http.Response, body []byte) *cacheEntry {
+	entry := &cacheEntry{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+	}
+	if json.Valid(body) {
+		entry.Body = body
+	} else {
+		entry.Text = string(body)
+	}
+
+	return entry
+}
+
+func newUSNCache(dir, ttl string, offline bool) (*usnCache, error) {
+	if dir == "" {
+		if offline {
+			return nil, errors.New("--offline requires --cache-dir")
+		}
+		return nil, nil
+	}
+
+	duration, err := time.ParseDuration(ttl)
+	if err != nil {
+		return nil, fmt.Errorf("--cache-ttl is not a valid duration: %w", err)
+	}
+
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &usnCache{dir: dir, ttl: duration, offline: offline}, nil
+}
+
+func (c *usnCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// get returns the cached page for the key, or nil when there is none for the
+// URL.
+func (c *usnCache) get(key, url string) *cacheEntry {
+	content, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil
+	}
+
+	var entry cacheEntry
+	err = json.Unmarshal(content, &entry)
+	if err != nil || entry.URL != url {
+		return nil
+	}
+
+	return &entry
+}
+
+func (c *usnCache) fresh(entry *cacheEntry) bool {
+	return time.Since(entry.FetchedAt) < c.ttl
+}
+
+// put writes the entry to a temporary file and renames it into place, so
+// that runs sharing the cache never read a partially written page.
+func (c *usnCache) put(key string, entry *cacheEntry, out io.Writer) {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Fprintf(out, "Failed to cache notices for %s: %v\n", key, err)
+		return
+	}
+
+	file, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		fmt.Fprintf(out, "Failed to cache notices for %s: %v\n", key, err)
+		return
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), c.path(key))
+	}
+	if err != nil {
+		fmt.Fprintf(out, "Failed to cache notices for %s: %v\n", key, err)
+	}
+}
+
+// content returns the body of the response the entry caches.
+func (e *cacheEntry) content() []byte {
+	if e.Text != "" {
+		return []byte(e.Text)
+	}
+
+	return e.Body
+}
+
+func (e *cacheEntry) notices() ([]USN, error) {
+	var data struct {
+		Notices []USN `json:"notices"`
+	}
+	err := json.Unmarshal(e.Body, &data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cached notices for %s: %w", e.URL, err)
+	}
+
+	return data.Notices, nil
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DEBIAN_TRACKER_URL = "https://security-tracker.debian.org/tracker/data/json"
+	DEBIAN_DSA_LIST    = "https://salsa.debian.org/security-tracker-team/security-tracker/-/raw/master/data/DSA/list"
+	DEBIAN_DLA_LIST    = "https://salsa.debian.org/security-tracker-team/security-tracker/-/raw/master/data/DLA/list"
+)
+
+var (
+	// advisoryHeader matches the first line of an advisory in a DSA or DLA
+	// list, such as "[10 Jan 2025] DSA-5843-1 rsync - security update".
+	advisoryHeader = regexp.MustCompile(`^\[(\d{1,2} \w{3} \d{4})\] (D[SL]A-\d+(?:-\d+)?) (.*)$`)
+
+	// advisoryFix matches a fixed package of an advisory in a DSA or DLA
+	// list, such as "[bookworm] - rsync 3.2.7-1+deb12u1".
+	advisoryFix = regexp.MustCompile(`^\[([a-z]+)\] - (\S+) (\S+)`)
+)
+
+// debianSource fetches DSAs and DLAs from the lists of the Debian Security
+// Tracker, which key the fixed packages of each advisory by suite. When a
+// tracker URL is given, the urgency and description of their CVEs are read
+// from the tracker's JSON, otherwise their CVEs are left untriaged. The
+// lists are ordered newest first, so each is read up to its first last known
+// advisory, and like the pages of the Ubuntu API, to at most a page of
+// advisories per page to fetch.
+type debianSource struct {
+	trackerURL string
+	listURLs   []string
+	known      map[string]struct{}
+	paging     pageOptions
+	cache      *usnCache
+
+	// advisories are fetched once and shared by every suite
+	advisories func() ([]USN, error)
+}
+
+func newDebianSource(trackerURL string, listURLs []string, known []PatchedUsnsInputOutput, paging pageOptions, cache *usnCache) *debianSource {
+	s := &debianSource{
+		trackerURL: trackerURL,
+		listURLs:   listURLs,
+		known:      map[string]struct{}{},
+		paging:     paging,
+		cache:      cache,
+	}
+	for _, advisory := range known {
+		s.known[advisory.ID] = struct{}{}
+	}
+	s.advisories = sync.OnceValues(s.fetchAdvisories)
+
+	return s
+}
+
+func (s *debianSource) fetch(distro Distro, out io.Writer) ([]USN, error) {
+	advisories, err := s.advisories()
+	if err != nil {
+		return nil, err
+	}
+
+	var usns []USN
+	for _, advisory := range advisories {
+		if len(advisory.ReleasePackages[distro.Name]) > 0 {
+			usns = append(usns, advisory)
+		}
+	}
+
+	return usns, nil
+}
+
+// fetchAdvisories fetches the advisories of every suite once, while the
+// workers of the suites wait for them, so it logs straight to stdout.
+func (s *debianSource) fetchAdvisories() ([]USN, error) {
+	limit := s.paging.pages * pageSize
+	if s.paging.auto {
+		limit = s.paging.maxPages * pageSize
+	}
+
+	var advisories []USN
+	for i, listURL := range s.listURLs {
+		content, err := fetchDocument(listURL, s.cache, fmt.Sprintf("debian-advisories-%d", i), os.Stdout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch advisories from %s: %w", listURL, err)
+		}
+
+		list, err := parseAdvisoryList(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse advisories from %s: %w", listURL, err)
+		}
+
+		for i, advisory := range list {
+			if i == limit {
+				if s.paging.auto {
+					fmt.Printf("Stopped after %d advisories of %s without finding a last known advisory, older advisories may be missing\n", limit, listURL)
+				}
+				break
+			}
+
+			if _, ok := s.known[advisory.ID]; ok {
+				break
+			}
+
+			if !s.paging.cutoff.IsZero() {
+				published, err := time.Parse(publishedLayout, advisory.Published)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse publish date of %s: %w", advisory.ID, err)
+				}
+
+				if published.Before(s.paging.cutoff) {
+					break
+				}
+			}
+
+			advisories = append(advisories, advisory)
+		}
+	}
+
+	// the tracker JSON is tens of megabytes, so it is only fetched when asked
+	// for and there are advisories to describe
+	if s.trackerURL == "" || len(advisories) == 0 {
+		return advisories, nil
+	}
+
+	content, err := fetchDocument(s.trackerURL, s.cache, "debian-tracker", os.Stdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch CVEs from %s: %w", s.trackerURL, err)
+	}
+
+	var tracker debianTracker
+	err = json.Unmarshal(content, &tracker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CVEs from %s: %w", s.trackerURL, err)
+	}
+
+	for i := range advisories {
+		tracker.describe(&advisories[i])
+	}
+
+	return advisories, nil
+}
+
+// parseAdvisoryList parses a DSA or DLA list of the Debian Security Tracker,
+// see https://salsa.debian.org/security-tracker-team/security-tracker/-/tree/master/data.
+// Packages that were not affected in a suite, such as
+// "[bookworm] - rsync <not-affected>", are skipped.
+func parseAdvisoryList(content []byte) ([]USN, error) {
+	var advisories []USN
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if matches := advisoryHeader.FindStringSubmatch(line); matches != nil {
+			published, err := time.Parse("2 Jan 2006", matches[1])
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse publish date of %s: %w", matches[2], err)
+			}
+
+			advisories = append(advisories, USN{
+				ID:              matches[2],
+				Title:           matches[3],
+				Published:       published.Format(publishedLayout),
+				ReleasePackages: map[string][]ReleasePackage{},
+			})
+			continue
+		}
+
+		if len(advisories) == 0 || !strings.HasPrefix(line, "\t") {
+			continue
+		}
+
+		advisory := &advisories[len(advisories)-1]
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "{") && strings.HasSuffix(line, "}") {
+			for _, id := range strings.Fields(strings.Trim(line, "{}")) {
+				advisory.CVEs = append(advisory.CVEs, CVE{ID: id})
+			}
+			continue
+		}
+
+		if matches := advisoryFix.FindStringSubmatch(line); matches != nil {
+			if strings.HasPrefix(matches[3], "<") {
+				continue
+			}
+
+			advisory.ReleasePackages[matches[1]] = append(advisory.ReleasePackages[matches[1]], ReleasePackage{Name: matches[2], Version: matches[3]})
+		}
+	}
+
+	return advisories, scanner.Err()
+}
+
+// debianTracker is the JSON of the Debian Security Tracker, which holds the
+// CVEs of each source package, see
+// https://security-tracker.debian.org/tracker/data/json.
+type debianTracker map[string]map[string]struct {
+	Description string `json:"description"`
+	Releases    map[string]struct {
+		Urgency string `json:"urgency"`
+	} `json:"releases"`
+}
+
+// describe sets the description and priority of the CVEs of the advisory.
+// The priority is the highest urgency of the CVE in the suites the advisory
+// fixed it in.
+func (t debianTracker) describe(advisory *USN) {
+	for i, cve := range advisory.CVEs {
+		highest := -1
+		for suite, packages := range advisory.ReleasePackages {
+			for _, pkg := range packages {
+				entry, ok := t[pkg.Name][cve.ID]
+				if !ok {
+					continue
+				}
+
+				if cve.Description == "" {
+					cve.Description = entry.Description
+				}
+				highest = max(highest, priorityRank(debianPriority(entry.Releases[suite].Urgency)))
+			}
+		}
+
+		if highest >= 0 {
+			cve.Priority = priorities[highest]
+		}
+		advisory.CVEs[i] = cve
+	}
+}
+
+// debianPriority returns the priority of a Debian urgency, ignoring the
+// asterisks some urgencies are marked with. Urgencies without a priority, such
+// as "not yet assigned", are empty.
+func debianPriority(urgency string) string {
+	urgency = strings.TrimRight(urgency, "*")
+	if urgency == "unimportant" {
+		return "negligible"
+	}
+
+	if priorityRank(urgency) < 0 {
+		return ""
+	}
+
+	return urgency
+}
+
+func (s *debianSource) noticeURL(id string) string {
+	return fmt.Sprintf("https://security-tracker.debian.org/tracker/%s", id)
+}
+
+func (s *debianSource) cveURL(id string) string {
+	return fmt.Sprintf("https://security-tracker.debian.org/tracker/%s", id)
+}
+
+func (s *debianSource) ecosystem(distro Distro) string {
+	return fmt.Sprintf("Debian:%s", distro.Version)
+}
+
+func (s *debianSource) purl(name, version string, distro Distro) string {
+	return debPURL("debian", name, version, distro.Version)
+}
+
+// fetchDocument returns the content of the URL, which may be a file:// URL of
+// a local file, served from the cache under the key like pages of notices.
+// Retried requests are logged to out.
+func fetchDocument(url string, cache *usnCache, key string, out io.Writer) ([]byte, error) {
+	if path, ok := fileURLPath(url); ok {
+		return os.ReadFile(path)
+	}
+
+	entry, err := fetchCached(url, cache, key, func([]byte) error { return nil }, out)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry.content(), nil
+}
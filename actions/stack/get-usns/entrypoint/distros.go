@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// defaultDistros is the list of supported distros used when no
+// --distros-config is given. Add new Ubuntu releases to distros.json.
+//
+//go:embed distros.json
+var defaultDistros []byte
+
+// defaultDebianDistros is the list of supported Debian suites used with
+// --source debian when no --distros-config is given.
+//
+//go:embed debian-distros.json
+var defaultDebianDistros []byte
+
+// Distro is a supported Ubuntu release, such as noble, and its version, such
+// as 24.04.
+type Distro struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// loadDistros reads the supported distros from the given file, or from the
+// defaults when no file is given.
+func loadDistros(path string, defaults []byte) ([]Distro, error) {
+	content := defaults
+	if path != "" {
+		var err error
+		content, err = os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var distros []Distro
+	err := json.Unmarshal(content, &distros)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse distros config: %w", err)
+	}
+
+	for i, distro := range distros {
+		if distro.Name == "" || distro.Version == "" {
+			return nil, fmt.Errorf("failed to parse distros config: distro %d must have a name and a version", i)
+		}
+	}
+
+	return distros, nil
+}
+
+// selectDistros returns the supported distros with the given comma separated
+// names, in the order given.
+func selectDistros(supported []Distro, names string) ([]Distro, error) {
+	var selected []Distro
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+
+		found := false
+		for _, distro := range supported {
+			if distro.Name == name {
+				selected = append(selected, distro)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			var supportedNames []string
+			for _, distro := range supported {
+				supportedNames = append(supportedNames, distro.Name)
+			}
+			return nil, fmt.Errorf("--distro flag has to be one of the following values: %v", supportedNames)
+		}
+	}
+
+	return selected, nil
+}
+
+// distroUSNs are the USNs selected for a distro, along with the status of
+// each of them when installed versions are given, and the names of the
+// packages in every fetched notice.
+type distroUSNs struct {
+	distro   Distro
+	usns     []USN
+	statuses map[string]string
+	packages map[string]struct{}
+}
+
+// collectForDistros runs collect for each distro with at most the given
+// number of workers at once, returning the results in the order of the
+// distros. Each worker logs to its own buffer, which is printed once the
+// worker is done, so that the logs of distros do not interleave.
+func collectForDistros(distros []Distro, workers int, collect func(Distro, io.Writer) (distroUSNs, error)) ([]distroUSNs, error) {
+	results := make([]distroUSNs, len(distros))
+	errs := make([]error, len(distros))
+
+	var wg sync.WaitGroup
+	var stdout sync.Mutex
+	slots := make(chan struct{}, workers)
+	for i, distro := range distros {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			var out bytes.Buffer
+			fmt.Fprintf(&out, "Collecting USNs for %s...\n", distro.Name)
+			results[i], errs[i] = collect(distro, &out)
+
+			stdout.Lock()
+			defer stdout.Unlock()
+			_, _ = os.Stdout.Write(out.Bytes())
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", distros[i].Name, err)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	osvSchemaVersion = "1.6.0"
+	openVEXContext   = "https://openvex.dev/ns/v0.2.0"
+)
+
+type osvRecord struct {
+	SchemaVersion string         `json:"schema_version"`
+	ID            string         `json:"id"`
+	Modified      string         `json:"modified"`
+	Published     string         `json:"published"`
+	Upstream      []string       `json:"upstream,omitempty"`
+	Summary       string         `json:"summary"`
+	Affected      []osvAffected  `json:"affected"`
+	References    []osvReference `json:"references"`
+}
+
+type osvAffected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+		PURL      string `json:"purl"`
+	} `json:"package"`
+	Ranges []osvRange `json:"ranges"`
+}
+
+type osvRange struct {
+	Type   string              `json:"type"`
+	Events []map[string]string `json:"events"`
+}
+
+type osvReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type openVEXDocument struct {
+	Context    string             `json:"@context"`
+	ID         string             `json:"@id"`
+	Author     string             `json:"author"`
+	Timestamp  string             `json:"timestamp"`
+	Version    int                `json:"version"`
+	Statements []openVEXStatement `json:"statements"`
+}
+
+type openVEXStatement struct {
+	Vulnerability struct {
+		Name    string   `json:"name"`
+		Aliases []string `json:"aliases,omitempty"`
+	} `json:"vulnerability"`
+	Products []openVEXProduct `json:"products"`
+	Status   string           `json:"status"`
+}
+
+type openVEXProduct struct {
+	ID            string              `json:"@id"`
+	Subcomponents []map[string]string `json:"subcomponents,omitempty"`
+}
+
+// debPURL returns the package URL of a package of a Debian based distro, such
+// as ubuntu, see
+// https://github.com/package-url/purl-spec/blob/main/PURL-TYPES.rst#deb.
+func debPURL(vendor, name, version, distroVersion string) string {
+	purl := fmt.Sprintf("pkg:deb/%s/%s", vendor, url.PathEscape(name))
+	if version != "" {
+		purl += "@" + url.PathEscape(version)
+	}
+
+	return fmt.Sprintf("%s?distro=%s-%s", purl, vendor, distroVersion)
+}
+
+// publishedTimestamp returns the publish date of the USN as an RFC 3339
+// timestamp.
+func publishedTimestamp(usn USN) (string, error) {
+	published, err := time.Parse(publishedLayout, usn.Published)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse publish date of %s: %w", usn.ID, err)
+	}
+
+	return published.UTC().Format(time.RFC3339), nil
+}
+
+// transformUSNsToOSV returns an OSV record for each advisory, with a range for each
+// of its packages on each distro that ends at the fixed version, see
+// https://ossf.github.io/osv-schema/.
+func transformUSNsToOSV(results []distroUSNs, source advisorySource) ([]osvRecord, error) {
+	records := []osvRecord{}
+	index := map[string]int{}
+	for _, result := range results {
+		for _, usn := range result.usns {
+			i, ok := index[usn.ID]
+			if !ok {
+				record, err := newOSVRecord(usn, source)
+				if err != nil {
+					return nil, err
+				}
+
+				i = len(records)
+				index[usn.ID] = i
+				records = append(records, record)
+			}
+
+			seen := map[string]bool{}
+			for _, pkg := range usn.ReleasePackages[result.distro.Name] {
+				if seen[pkg.Name] {
+					continue
+				}
+				seen[pkg.Name] = true
+
+				var affected osvAffected
+				affected.Package.Ecosystem = source.ecosystem(result.distro)
+				affected.Package.Name = pkg.Name
+				affected.Package.PURL = source.purl(pkg.Name, "", result.distro)
+				affected.Ranges = []osvRange{{
+					Type:   "ECOSYSTEM",
+					Events: []map[string]string{{"introduced": "0"}, {"fixed": pkg.Version}},
+				}}
+
+				records[i].Affected = append(records[i].Affected, affected)
+			}
+		}
+	}
+
+	return records, nil
+}
+
+func newOSVRecord(usn USN, source advisorySource) (osvRecord, error) {
+	published, err := publishedTimestamp(usn)
+	if err != nil {
+		return osvRecord{}, err
+	}
+
+	record := osvRecord{
+		SchemaVersion: osvSchemaVersion,
+		ID:            usn.ID,
+		Modified:      published,
+		Published:     published,
+		Summary:       usn.Title,
+		Affected:      []osvAffected{},
+		References: []osvReference{{
+			Type: "ADVISORY",
+			URL:  source.noticeURL(usn.ID),
+		}},
+	}
+
+	for _, cve := range usn.CVEs {
+		record.Upstream = append(record.Upstream, cve.ID)
+		record.References = append(record.References, osvReference{
+			Type: "REPORT",
+			URL:  source.cveURL(cve.ID),
+		})
+	}
+
+	return record, nil
+}
+
+// transformUSNsToOpenVEX returns an OpenVEX document stating that the product
+// fixed each USN, with the stack packages it fixed on each distro as
+// subcomponents, see https://github.com/openvex/spec.
+func transformUSNsToOpenVEX(results []distroUSNs, source advisorySource, matcher packageMatcher, product, author string, timestamp time.Time) (openVEXDocument, error) {
+	document := openVEXDocument{
+		Context:    openVEXContext,
+		Author:     author,
+		Timestamp:  timestamp.UTC().Format(time.RFC3339),
+		Version:    1,
+		Statements: []openVEXStatement{},
+	}
+
+	index := map[string]int{}
+	for _, result := range results {
+		for _, usn := range result.usns {
+			i, ok := index[usn.ID]
+			if !ok {
+				statement := openVEXStatement{Status: "fixed"}
+				statement.Vulnerability.Name = usn.ID
+				for _, cve := range usn.CVEs {
+					statement.Vulnerability.Aliases = append(statement.Vulnerability.Aliases, cve.ID)
+				}
+				statement.Products = []openVEXProduct{{ID: product}}
+
+				i = len(document.Statements)
+				index[usn.ID] = i
+				document.Statements = append(document.Statements, statement)
+			}
+
+			subject := &document.Statements[i].Products[0]
+			seen := map[string]bool{}
+			for _, pkg := range usn.ReleasePackages[result.distro.Name] {
+				if _, ok := matcher.match(pkg.Name); seen[pkg.Name] || (!matcher.empty() && !ok) {
+					continue
+				}
+				seen[pkg.Name] = true
+
+				subject.Subcomponents = append(subject.Subcomponents, map[string]string{"@id": source.purl(pkg.Name, pkg.Version, result.distro)})
+			}
+		}
+	}
+
+	// identify the document by its statements, so the same fixes always
+	// produce the same ID
+	statements, err := json.Marshal(document.Statements)
+	if err != nil {
+		return openVEXDocument{}, err
+	}
+	document.ID = fmt.Sprintf("https://openvex.dev/docs/public/vex-%x", sha256.Sum256(statements))
+
+	return document, nil
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, os.ModePerm)
+}
@@ -4,6 +4,7 @@ go 1.24.1
 
 require (
 	github.com/onsi/gomega v1.39.1
+	github.com/paketo-buildpacks/github-config/internal/debversion v0.0.0
 	github.com/sclevine/spec v1.4.0
 )
 
@@ -13,3 +14,5 @@ require (
 	golang.org/x/net v0.49.0 // indirect
 	golang.org/x/text v0.33.0 // indirect
 )
+
+replace github.com/paketo-buildpacks/github-config/internal/debversion => ../../../../internal/debversion
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// readInput returns the value of a flag given either inline or as a file its
+// -filepath variant points to, along with where it came from for errors. It
+// returns nil when neither is given.
+func readInput(name, inline, path string) ([]byte, string, error) {
+	switch {
+	case inline != "" && path != "":
+		return nil, "", fmt.Errorf("--%s and --%s-filepath cannot both be given", name, name)
+	case path != "":
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid --%s-filepath: %w", name, err)
+		}
+		return content, fmt.Sprintf("--%s-filepath %s", name, path), nil
+	case inline != "":
+		return []byte(inline), "--" + name, nil
+	default:
+		return nil, "", nil
+	}
+}
+
+// decodeArray decodes a JSON array from the input, passing each item to
+// decode so that errors name the item that was malformed.
+func decodeArray(origin string, content []byte, decode func(item json.RawMessage) error) error {
+	var items []json.RawMessage
+	err := json.Unmarshal(content, &items)
+	if err != nil {
+		return fmt.Errorf("invalid %s: must be a JSON array: %w", origin, err)
+	}
+
+	for i, item := range items {
+		err = decode(item)
+		if err != nil {
+			return fmt.Errorf("invalid %s: item at index %d %w", origin, i, err)
+		}
+	}
+
+	return nil
+}
+
+// loadPackages reads the JSON array of package names given by --packages or
+// --packages-filepath.
+func loadPackages(inline, path string) ([]string, error) {
+	content, origin, err := readInput("packages", inline, path)
+	if err != nil || content == nil {
+		return []string{}, err
+	}
+
+	packages := []string{}
+	err = decodeArray(origin, content, func(item json.RawMessage) error {
+		var name string
+		err := json.Unmarshal(item, &name)
+		if err != nil || name == "" {
+			return fmt.Errorf("must be a package name, got %s", item)
+		}
+
+		packages = append(packages, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return packages, nil
+}
+
+// loadLastUSNs reads the JSON array of last known USNs given by --last-usns
+// or --last-usns-filepath, which is the output of a previous run.
+func loadLastUSNs(inline, path string) ([]PatchedUsnsInputOutput, error) {
+	content, origin, err := readInput("last-usns", inline, path)
+	if err != nil || content == nil {
+		return []PatchedUsnsInputOutput{}, err
+	}
+
+	usns := []PatchedUsnsInputOutput{}
+	err = decodeArray(origin, content, func(item json.RawMessage) error {
+		var usn PatchedUsnsInputOutput
+		err := json.Unmarshal(item, &usn)
+		if err != nil {
+			return fmt.Errorf("must be a USN object: %w", err)
+		}
+
+		if usn.ID == "" {
+			return fmt.Errorf("must have an id, got %s", item)
+		}
+
+		usns = append(usns, usn)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return usns, nil
+}
+
+// warnAboutUnknownPackages warns in a single annotation about the packages
+// that appear neither in any of the fetched notices, by their own names or
+// those of their source packages, nor among the installed packages or their
+// source packages, which often means there is a typo in the package list.
+// Only a few notices are fetched on most runs, so packages that are installed
+// or mapped to a source package are not warned about.
+func warnAboutUnknownPackages(packages []string, sources, installed map[string]string, results []distroUSNs, out io.Writer) {
+	known := map[string]struct{}{}
+	for name, source := range sources {
+		known[name] = struct{}{}
+		known[source] = struct{}{}
+	}
+	for name := range installed {
+		known[name] = struct{}{}
+	}
+
+	var unknown []string
+	for _, pkg := range packages {
+		if _, ok := known[pkg]; ok {
+			continue
+		}
+
+		found := false
+		for _, result := range results {
+			if _, ok := result.packages[pkg]; ok {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			unknown = append(unknown, pkg)
+		}
+	}
+
+	if len(unknown) > 0 {
+		fmt.Fprintf(out, "::warning::Packages that appear in no fetched notice or installed package, check the package list for a typo: %s\n", strings.Join(unknown, ", "))
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -11,53 +12,86 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
 
 const JSON_API_URL = "https://ubuntu.com/security/notices.json"
 
-var supportedDistros = []string{
-	"noble",
-	"jammy",
-	"focal",
-	"bionic",
-	"resolute",
+type CVE struct {
+	ID          string   `json:"id"`
+	Priority    string   `json:"priority"`
+	CVSS3       *float64 `json:"cvss3"`
+	Description string   `json:"description"`
 }
 
+type ReleasePackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// USN is a security advisory, as given by the Ubuntu notices JSON API. The
+// advisories of other sources, such as Debian DSAs, are read into it as well.
 type USN struct {
-	CVEs []struct {
-		ID string `json:"id"`
-	} `json:"cves"`
-	Title           string `json:"title"`
-	ID              string `json:"id"`
-	ReleasePackages map[string][]struct {
-		Name    string `json:"name"`
-		Version string `json:"version"`
-	} `json:"release_packages"`
+	CVEs            []CVE                       `json:"cves"`
+	Title           string                      `json:"title"`
+	ID              string                      `json:"id"`
+	Published       string                      `json:"published"`
+	ReleasePackages map[string][]ReleasePackage `json:"release_packages"`
+}
+
+type PatchedCVE struct {
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+	Priority    string   `json:"priority,omitempty"`
+	CVSSScore   *float64 `json:"cvss_score,omitempty"`
+	Description string   `json:"description,omitempty"`
 }
 
 type PatchedUsnsInputOutput struct {
-	AffectedPackages []string `json:"affected_packages"`
-	CVEs             []struct {
-		Title string `json:"title"`
-		URL   string `json:"url"`
-	} `json:"cves"`
-	Title string `json:"title"`
-	ID    string `json:"id"`
-	URL   string `json:"url"`
+	AffectedPackages []string     `json:"affected_packages"`
+	CVEs             []PatchedCVE `json:"cves"`
+	Title            string       `json:"title"`
+	ID               string       `json:"id"`
+	URL              string       `json:"url"`
+	Priority         string       `json:"priority,omitempty"`
+	Status           string       `json:"status,omitempty"`
 }
 
 func main() {
 	var config struct {
-		APIUrl               string
-		Distro               string
-		LastUSNsJSON         string
-		LastUSNsJSONFilepath string
-		Output               string
-		PackagesJSON         string
-		PackagesJSONFilepath string
-		Pages                int
+		APIUrl                    string
+		Source                    string
+		AdvisoryLists             string
+		Distro                    string
+		Distros                   string
+		DistrosConfig             string
+		Workers                   int
+		LastUSNsJSON              string
+		LastUSNsJSONFilepath      string
+		Output                    string
+		PackagesJSON              string
+		PackagesJSONFilepath      string
+		Pages                     string
+		MaxPages                  int
+		Cutoff                    string
+		Since                     string
+		Until                     string
+		CacheDir                  string
+		CacheTTL                  string
+		Offline                   bool
+		MinPriority               string
+		OSVOutput                 string
+		OpenVEXOutput             string
+		OpenVEXProduct            string
+		OpenVEXAuthor             string
+		InstalledVersions         string
+		InstalledVersionsFilepath string
+		PreviousVersions          string
+		PreviousVersionsFilepath  string
+		PackageSources            string
+		PackageSourcesFilepath    string
 	}
 
 	flag.StringVar(&config.LastUSNsJSON,
@@ -68,10 +102,18 @@ func main() {
 		"last-usns-filepath",
 		"",
 		"Filepath that points to the JSON array of last known USNs")
+	flag.StringVar(&config.Source,
+		"source",
+		"ubuntu",
+		"Source of advisories: ubuntu for USNs or debian for DSAs and DLAs")
 	flag.StringVar(&config.APIUrl,
 		"api-url",
-		JSON_API_URL,
-		"URL of the Ubuntu security notices JSON API (https or file:// for a local JSON file)")
+		"",
+		"URL of the Ubuntu security notices JSON API, or of the Debian Security Tracker JSON to read the priorities and descriptions of CVEs from with --source debian (https or file:// for a local JSON file). With --source debian, the tracker JSON is only fetched when this or --min-priority is given")
+	flag.StringVar(&config.AdvisoryLists,
+		"advisory-lists",
+		"",
+		"Comma separated URLs of the DSA and DLA lists of the Debian Security Tracker, used with --source debian (default: the lists in the security-tracker repository)")
 	flag.StringVar(&config.PackagesJSON,
 		"packages",
 		"",
@@ -80,97 +122,337 @@ func main() {
 		"packages-filepath",
 		"",
 		"Filepath that points to the JSON array of relevant packages")
+	flag.StringVar(&config.PackageSources,
+		"package-sources",
+		"",
+		"JSON object of binary package names to source package names, so that packages also match notices of their source packages")
+	flag.StringVar(&config.PackageSourcesFilepath,
+		"package-sources-filepath",
+		"",
+		"Filepath that points to a dpkg status file or JSON object of binary package names to source package names")
 	flag.StringVar(&config.Distro,
 		"distro",
 		"",
-		"Name of Ubuntu distro: bionic, focal, jammy, noble")
+		"Name of distro, such as noble for Ubuntu or bookworm for Debian")
+	flag.StringVar(&config.Distros,
+		"distros",
+		"",
+		"Comma separated names of distros, whose advisories are output keyed by distro")
+	flag.StringVar(&config.DistrosConfig,
+		"distros-config",
+		"",
+		"Filepath that points to a JSON array of the supported distros and their versions")
+	flag.IntVar(&config.Workers,
+		"workers",
+		4,
+		"Most distros to fetch USNs for at once")
 	flag.StringVar(&config.Output,
 		"output",
 		"",
 		"Path to output JSON file")
-	flag.IntVar(&config.Pages,
+	flag.StringVar(&config.Pages,
 		"pages",
-		1,
-		"Number of pages to fetch from the API (default: 1)")
+		"",
+		"Number of pages to fetch from the API, or auto to fetch until a last known USN is found (default: 1, or auto with --since). With --source debian, each page is 20 advisories of each advisory list")
+	flag.IntVar(&config.MaxPages,
+		"max-pages",
+		50,
+		"Most pages to fetch from the API when --pages is auto")
+	flag.StringVar(&config.Cutoff,
+		"cutoff",
+		"",
+		"Oldest publish date, such as 2025-12-01, to fetch notices back to when --pages is auto")
+	flag.StringVar(&config.Since,
+		"since",
+		"",
+		"Oldest publish date, such as 2025-12-01, of USNs to output. Pages are fetched back to this date, so it requires --pages auto, which it defaults to")
+	flag.StringVar(&config.Until,
+		"until",
+		"",
+		"Publish date, such as 2026-01-01, that USNs to output were published before")
+	flag.StringVar(&config.CacheDir,
+		"cache-dir",
+		"",
+		"Directory to cache pages of notices, or the Debian advisory lists and tracker, in, shared between runs")
+	flag.StringVar(&config.CacheTTL,
+		"cache-ttl",
+		"1h",
+		"How long cached pages are used before they are revalidated")
+	flag.BoolVar(&config.Offline,
+		"offline",
+		false,
+		"Serve pages only from the cache, without requesting the API")
+	flag.StringVar(&config.MinPriority,
+		"min-priority",
+		"",
+		"Lowest CVE priority of USNs to output: negligible, low, medium, high or critical")
+	flag.StringVar(&config.OSVOutput,
+		"osv-output",
+		"",
+		"Path to output the USNs as a JSON array of OSV records")
+	flag.StringVar(&config.OpenVEXOutput,
+		"openvex-output",
+		"",
+		"Path to output an OpenVEX document stating the USNs are fixed in --openvex-product")
+	flag.StringVar(&config.OpenVEXProduct,
+		"openvex-product",
+		"",
+		"Identifier of the stack image, such as a package URL, that the OpenVEX statements are about")
+	flag.StringVar(&config.OpenVEXAuthor,
+		"openvex-author",
+		"Paketo Buildpacks",
+		"Author of the OpenVEX document")
+	flag.StringVar(&config.InstalledVersions,
+		"installed-versions",
+		"",
+		"JSON object of installed package names to versions, to output only the USNs they fixed since --previous-versions")
+	flag.StringVar(&config.InstalledVersionsFilepath,
+		"installed-versions-filepath",
+		"",
+		"Filepath that points to a CycloneDX receipt or JSON object of installed package versions")
+	flag.StringVar(&config.PreviousVersions,
+		"previous-versions",
+		"",
+		"JSON object of package names to versions installed in the previous release")
+	flag.StringVar(&config.PreviousVersionsFilepath,
+		"previous-versions-filepath",
+		"",
+		"Filepath that points to a CycloneDX receipt or JSON object of package versions installed in the previous release")
 
 	flag.Parse()
 
-	if !slices.Contains(supportedDistros, config.Distro) {
-		log.Fatalf("--distro flag has to be one of the following values: %v", supportedDistros)
+	var defaults []byte
+	switch config.Source {
+	case "ubuntu":
+		defaults = defaultDistros
+		if config.APIUrl == "" {
+			config.APIUrl = JSON_API_URL
+		}
+	case "debian":
+		defaults = defaultDebianDistros
+		// the priorities of CVEs are only known from the tracker JSON
+		if config.APIUrl == "" && config.MinPriority != "" {
+			config.APIUrl = DEBIAN_TRACKER_URL
+		}
+		if config.AdvisoryLists == "" {
+			config.AdvisoryLists = strings.Join([]string{DEBIAN_DSA_LIST, DEBIAN_DLA_LIST}, ",")
+		}
+	default:
+		log.Fatalf("--source has to be one of the following values: [ubuntu debian], got %q", config.Source)
 	}
-	if config.Pages < 1 {
-		log.Fatalf("--pages must be at least 1, got %d", config.Pages)
+
+	supportedDistros, err := loadDistros(config.DistrosConfig, defaults)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	lastPatchedUSNs := []PatchedUsnsInputOutput{}
-	if config.LastUSNsJSON != "" {
-		err := json.Unmarshal([]byte(config.LastUSNsJSON), &lastPatchedUSNs)
-		if err != nil {
-			log.Fatal(err)
+	if config.Distro != "" && config.Distros != "" {
+		log.Fatal("--distro and --distros cannot both be given")
+	}
+
+	names := config.Distro
+	if config.Distros != "" {
+		names = config.Distros
+	}
+
+	distros, err := selectDistros(supportedDistros, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if config.Workers < 1 {
+		log.Fatalf("--workers must be at least 1, got %d", config.Workers)
+	}
+
+	// --since pages back to its date, which only auto paging does
+	if config.Pages == "" {
+		config.Pages = "1"
+		if config.Since != "" {
+			config.Pages = "auto"
 		}
 	}
 
-	if config.LastUSNsJSONFilepath != "" {
+	paging, err := parsePaging(config.Pages, config.MaxPages, config.Cutoff)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-		lastUSNsFilepath, err := os.ReadFile(config.LastUSNsJSONFilepath)
-		if err != nil {
-			log.Fatal(err)
+	window, err := parseDateWindow(config.Since, config.Until)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if config.Since != "" && !paging.auto {
+		log.Fatal("--since requires --pages auto")
+	}
+
+	// notices published before --since are never output, so there is no need
+	// to page back any further
+	if paging.cutoff.Before(window.since) {
+		paging.cutoff = window.since
+	}
+
+	cache, err := newUSNCache(config.CacheDir, config.CacheTTL, config.Offline)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if config.MinPriority != "" && priorityRank(config.MinPriority) < 0 {
+		log.Fatalf("--min-priority has to be one of the following values: %v", priorities)
+	}
+
+	if config.OpenVEXOutput != "" && config.OpenVEXProduct == "" {
+		log.Fatal("--openvex-output requires --openvex-product")
+	}
+
+	lastPatchedUSNs, err := loadLastUSNs(config.LastUSNsJSON, config.LastUSNsJSONFilepath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	packages, err := loadPackages(config.PackagesJSON, config.PackagesJSONFilepath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	installedVersions, err := loadPackageVersions("installed-versions", config.InstalledVersions, config.InstalledVersionsFilepath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	previousVersions, err := loadPackageVersions("previous-versions", config.PreviousVersions, config.PreviousVersionsFilepath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	packageSources, err := loadPackageSources(config.PackageSources, config.PackageSourcesFilepath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if previousVersions != nil && installedVersions == nil {
+		log.Fatal("--previous-versions requires --installed-versions")
+	}
+
+	// without the previous versions, a USN fixed by the installed versions
+	// cannot be told to be fixed in this release
+	if installedVersions != nil && previousVersions == nil {
+		log.Fatal("--installed-versions requires --previous-versions")
+	}
+
+	// only warn about the packages given, as most installed packages are
+	// never in a notice
+	listedPackages := packages
+
+	// match on every installed package when no packages are given
+	if len(packages) == 0 {
+		for name := range installedVersions {
+			packages = append(packages, name)
 		}
+		slices.Sort(packages)
+	}
 
-		err = json.Unmarshal(lastUSNsFilepath, &lastPatchedUSNs)
+	matcher := newPackageMatcher(packages, packageSources)
+
+	var source advisorySource = ubuntuSource{
+		apiURL: config.APIUrl,
+		known:  lastPatchedUSNs,
+		paging: paging,
+		cache:  cache,
+	}
+	if config.Source == "debian" {
+		source = newDebianSource(config.APIUrl, strings.Split(config.AdvisoryLists, ","), lastPatchedUSNs, paging, cache)
+	}
+
+	results, err := collectForDistros(distros, config.Workers, func(distro Distro, out io.Writer) (distroUSNs, error) {
+		newUSNs, err := source.fetch(distro, out)
 		if err != nil {
-			log.Fatal(err)
+			return distroUSNs{}, err
+		}
+
+		fmt.Fprintln(out, "Recent USNs found:")
+		for _, usn := range newUSNs {
+			fmt.Fprintf(out, "%s with name %s\n", usn.ID, usn.Title)
+		}
+
+		if !window.isOpen() {
+			newUSNs, err = filterUSNsByPublishDate(newUSNs, window, out)
+			if err != nil {
+				return distroUSNs{}, err
+			}
 		}
+
+		result := distroUSNs{distro: distro, packages: map[string]struct{}{}}
+		for _, usn := range newUSNs {
+			for _, pkg := range usn.ReleasePackages[distro.Name] {
+				result.packages[pkg.Name] = struct{}{}
+			}
+		}
+
+		result.usns = filterUSNsByPackages(newUSNs, matcher, distro.Name, out)
+
+		if installedVersions != nil {
+			result.usns, result.statuses, err = filterUSNsByVersions(result.usns, distro.Name, installedVersions, previousVersions, packageSources, out)
+			if err != nil {
+				return distroUSNs{}, err
+			}
+		}
+
+		if config.MinPriority != "" {
+			result.usns = filterUSNsByPriority(result.usns, config.MinPriority, out)
+		}
+
+		return result, nil
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	packages := []string{}
-	if config.PackagesJSON != "" {
-		err := json.Unmarshal([]byte(config.PackagesJSON), &packages)
-		if err != nil {
-			log.Fatal(err)
+	warnAboutUnknownPackages(listedPackages, packageSources, installedVersions, results, os.Stderr)
+
+	keyed := map[string][]PatchedUsnsInputOutput{}
+	for _, result := range results {
+		transformed := transformUSNsForOutput(result.usns, result.distro.Name, source)
+		for i := range transformed {
+			transformed[i].Status = result.statuses[transformed[i].ID]
 		}
+		keyed[result.distro.Name] = transformed
 	}
 
-	if config.PackagesJSONFilepath != "" {
-		packagesFilepath, err := os.ReadFile(config.PackagesJSONFilepath)
+	var output []byte
+	if config.Distros != "" {
+		output, err = json.Marshal(keyed)
+	} else {
+		output, err = json.Marshal(keyed[distros[0].Name])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if config.OSVOutput != "" {
+		records, err := transformUSNsToOSV(results, source)
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		err = json.Unmarshal(packagesFilepath, &packages)
+		err = writeJSONFile(config.OSVOutput, records)
 		if err != nil {
 			log.Fatal(err)
 		}
 	}
 
-	var newUSNs []USN
-	var err error
-	if path, ok := fileURLPath(config.APIUrl); ok {
-		newUSNs, err = getNewUSNsFromFilepath(path)
+	if config.OpenVEXOutput != "" {
+		document, err := transformUSNsToOpenVEX(results, source, matcher, config.OpenVEXProduct, config.OpenVEXAuthor, time.Now())
 		if err != nil {
 			log.Fatal(err)
 		}
-	} else {
-		newUSNs, err = getNewUSNsFromJSONApi(config.APIUrl, lastPatchedUSNs, config.Distro, config.Pages)
+
+		err = writeJSONFile(config.OpenVEXOutput, document)
 		if err != nil {
 			log.Fatal(err)
 		}
 	}
 
-	fmt.Println("Recent USNs found:")
-	for _, usn := range newUSNs {
-		fmt.Printf("%s with name %s\n", usn.ID, usn.Title)
-	}
-
-	filteredUSNs := filterUSNsByPackages(newUSNs, packages, config.Distro)
-
-	transformed := transformUSNsForOutput(filteredUSNs, config.Distro)
-
-	output, err := json.Marshal(transformed)
-	if err != nil {
-		log.Fatal(err)
-	}
-
 	if config.Output != "" {
 		path, err := filepath.Abs(config.Output)
 		if err != nil {
@@ -195,29 +477,30 @@ func main() {
 	}
 }
 
-func filterUSNsByPackages(usns []USN, packages []string, distro string) (filtered []USN) {
-	if len(packages) == 0 {
-		fmt.Println("No packages specified. Skipping filtering.")
+func filterUSNsByPackages(usns []USN, matcher packageMatcher, distro string, out io.Writer) (filtered []USN) {
+	if matcher.empty() {
+		fmt.Fprintln(out, "No packages specified. Skipping filtering.")
 		return usns
 	}
 
-	fmt.Println("Filtering USNs by affected packages...")
+	fmt.Fprintln(out, "Filtering USNs by affected packages...")
 	for _, usn := range usns {
-	matchPkgs:
 		for _, affected := range usn.ReleasePackages[distro] {
-			for _, pkg := range packages {
+			if pkg, ok := matcher.match(affected.Name); ok {
+				filtered = append(filtered, usn)
 				if pkg == affected.Name {
-					filtered = append(filtered, usn)
-					fmt.Printf("USN '%s' contains affected package '%s'\n", usn.Title, affected.Name)
-					break matchPkgs
+					fmt.Fprintf(out, "USN '%s' contains affected package '%s'\n", usn.Title, affected.Name)
+				} else {
+					fmt.Fprintf(out, "USN '%s' contains affected source package '%s' of package '%s'\n", usn.Title, affected.Name, pkg)
 				}
+				break
 			}
 		}
 	}
 	return filtered
 }
 
-func transformUSNsForOutput(usns []USN, distro string) []PatchedUsnsInputOutput {
+func transformUSNsForOutput(usns []USN, distro string, source advisorySource) []PatchedUsnsInputOutput {
 	output := []PatchedUsnsInputOutput{}
 	for _, usn := range usns {
 		var packageNames []string
@@ -225,17 +508,14 @@ func transformUSNsForOutput(usns []USN, distro string) []PatchedUsnsInputOutput
 			packageNames = append(packageNames, pkg.Name)
 		}
 
-		var cves []struct {
-			Title string `json:"title"`
-			URL   string `json:"url"`
-		}
+		var cves []PatchedCVE
 		for _, cve := range usn.CVEs {
-			cves = append(cves, struct {
-				Title string `json:"title"`
-				URL   string `json:"url"`
-			}{
-				Title: cve.ID,
-				URL:   fmt.Sprintf("https://ubuntu.com/security/%s", cve.ID),
+			cves = append(cves, PatchedCVE{
+				Title:       cve.ID,
+				URL:         source.cveURL(cve.ID),
+				Priority:    cve.Priority,
+				CVSSScore:   cve.CVSS3,
+				Description: cve.Description,
 			})
 		}
 
@@ -243,8 +523,9 @@ func transformUSNsForOutput(usns []USN, distro string) []PatchedUsnsInputOutput
 			ID:               usn.ID,
 			Title:            fmt.Sprintf("%s: %s", usn.ID, usn.Title),
 			CVEs:             cves,
-			URL:              fmt.Sprintf("https://ubuntu.com/security/notices/%s", usn.ID),
+			URL:              source.noticeURL(usn.ID),
 			AffectedPackages: packageNames,
+			Priority:         highestPriority(usn),
 		})
 	}
 	return output
@@ -279,44 +560,99 @@ func getNewUSNsFromFilepath(path string) ([]USN, error) {
 
 const pageSize = 20
 
-func getNewUSNsFromJSONApi(jsonApiUrl string, lastPatchedUSNs []PatchedUsnsInputOutput, distro string, numPages int) ([]USN, error) {
-	var allUSNs []USN
+// publishedLayout is the layout of the publish dates of notices, which are
+// given in UTC without a time zone.
+const publishedLayout = "2006-01-02T15:04:05.999999"
+
+// pageOptions is how many pages of notices to fetch. In auto mode, pages are
+// fetched until one holds a last known USN or a notice published before the
+// cutoff, up to the most pages.
+type pageOptions struct {
+	pages    int
+	auto     bool
+	maxPages int
+	cutoff   time.Time
+}
+
+func parsePaging(pages string, maxPages int, cutoff string) (pageOptions, error) {
+	if pages == "auto" {
+		if maxPages < 1 {
+			return pageOptions{}, fmt.Errorf("--max-pages must be at least 1, got %d", maxPages)
+		}
+
+		p := pageOptions{auto: true, maxPages: maxPages}
+		if cutoff != "" {
+			var err error
+			p.cutoff, err = parseTimestamp("cutoff", cutoff)
+			if err != nil {
+				return pageOptions{}, err
+			}
+		}
+
+		return p, nil
+	}
+
+	if cutoff != "" {
+		return pageOptions{}, errors.New("--cutoff requires --pages auto")
+	}
+
+	n, err := strconv.Atoi(pages)
+	if err != nil || n < 1 {
+		return pageOptions{}, fmt.Errorf("--pages must be at least 1 or auto, got %q", pages)
+	}
+
+	return pageOptions{pages: n}, nil
+}
+
+func getNewUSNsFromJSONApi(jsonApiUrl string, lastPatchedUSNs []PatchedUsnsInputOutput, distro string, paging pageOptions, cache *usnCache, out io.Writer) ([]USN, error) {
+	known := map[string]struct{}{}
+	for _, usn := range lastPatchedUSNs {
+		known[usn.ID] = struct{}{}
+	}
+
+	var newUSNs []USN
+	for page := 0; paging.auto || page < paging.pages; page++ {
+		if paging.auto && page == paging.maxPages {
+			fmt.Fprintf(out, "Stopped after %d pages without finding a last known USN, older notices may be missing\n", page)
+			break
+		}
 
-	for page := 0; page < numPages; page++ {
 		offset := page * pageSize
 		paginatedUrl := fmt.Sprintf("%s?release=%s&limit=%d&offset=%d", jsonApiUrl, distro, pageSize, offset)
-		usns, err := fetchUSNPage(paginatedUrl)
+		usns, err := fetchUSNPage(paginatedUrl, cache, fmt.Sprintf("%s-%d", distro, offset), out)
 		if err != nil {
 			return nil, err
 		}
-		allUSNs = append(allUSNs, usns...)
-	}
 
-	var newUSNs []USN
-	for _, usn := range allUSNs {
+		done := len(usns) == 0
+		for _, usn := range usns {
+			if _, ok := known[usn.ID]; ok {
+				done = true
+				continue
+			}
 
-		if !isNewUSN(usn.ID, lastPatchedUSNs) {
-			continue
-		}
+			if !paging.cutoff.IsZero() {
+				published, err := time.Parse(publishedLayout, usn.Published)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse publish date of %s: %w", usn.ID, err)
+				}
 
-		newUSNs = append(newUSNs, USN{
-			ID:              usn.ID,
-			Title:           usn.Title,
-			CVEs:            usn.CVEs,
-			ReleasePackages: usn.ReleasePackages,
-		})
-	}
+				if published.Before(paging.cutoff) {
+					done = true
+					continue
+				}
+			}
 
-	return newUSNs, nil
-}
+			newUSNs = append(newUSNs, usn)
+		}
 
-func isNewUSN(id string, lastPatchedUSNs []PatchedUsnsInputOutput) bool {
-	for _, lastPatchedUSN := range lastPatchedUSNs {
-		if id == lastPatchedUSN.ID {
-			return false
+		if paging.auto && done {
+			fmt.Fprintf(out, "Fetched %d pages of notices\n", page+1)
+			break
 		}
 	}
-	return true
+
+	return newUSNs, nil
 }
 
 const (
@@ -325,38 +661,121 @@ const (
 	retryDelay  = 5 * time.Second
 )
 
-func fetchUSNPage(url string) ([]USN, error) {
+// fetchUSNPage returns the notices on a page, served from the cache when it
+// holds a fresh copy.
+func fetchUSNPage(url string, cache *usnCache, key string, out io.Writer) ([]USN, error) {
+	entry, err := fetchCached(url, cache, key, validateNotices, out)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry.notices()
+}
+
+// fetchCached returns the response to a request for the URL, served from the
+// cache when it holds a fresh copy. A stale copy is revalidated, and served
+// when the URL cannot be reached.
+func fetchCached(url string, cache *usnCache, key string, validate func([]byte) error, out io.Writer) (*cacheEntry, error) {
+	var entry *cacheEntry
+	if cache != nil {
+		entry = cache.get(key, url)
+		switch {
+		case entry != nil && cache.offline:
+			return entry, nil
+		case cache.offline:
+			return nil, fmt.Errorf("no cached notices for %s in offline mode", key)
+		case entry != nil && cache.fresh(entry):
+			fmt.Fprintf(out, "Using cached notices for %s\n", key)
+			return entry, nil
+		}
+	}
+
+	header := http.Header{}
+	if entry != nil {
+		if entry.ETag != "" {
+			header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
 
-	type USNsResponse struct {
+	resp, body, err := requestURL(url, header, validate, out)
+	if err != nil {
+		if entry != nil {
+			fmt.Fprintf(out, "Using stale cached notices for %s: %v\n", key, err)
+			return entry, nil
+		}
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		fmt.Fprintf(out, "Cached notices for %s are still current\n", key)
+		entry.FetchedAt = time.Now()
+		cache.put(key, entry, out)
+		return entry, nil
+	}
+
+	entry = newCacheEntry(url, resp, body)
+	if cache != nil {
+		cache.put(key, entry, out)
+	}
+
+	return entry, nil
+}
+
+// validateNotices checks that the body of a page holds notices.
+func validateNotices(body []byte) error {
+	var data struct {
 		Notices []USN `json:"notices"`
 	}
+	return json.Unmarshal(body, &data)
+}
 
+// requestURL requests the URL, retrying failed requests and those whose body
+// is not valid, and returns the response along with its body. Failed
+// attempts are logged to out.
+func requestURL(url string, header http.Header, validate func([]byte) error, out io.Writer) (*http.Response, []byte, error) {
 	client := &http.Client{Timeout: httpTimeout}
 	for attempt := range maxRetries {
 		if attempt > 0 {
-			log.Printf("Retrying request to %s (attempt %d/%d) after %v seconds", url, attempt+1, maxRetries, retryDelay.Seconds())
+			fmt.Fprintf(out, "Retrying request to %s (attempt %d/%d) after %v seconds\n", url, attempt+1, maxRetries, retryDelay.Seconds())
 			time.Sleep(retryDelay)
 		}
 
-		resp, err := client.Get(url)
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header = header.Clone()
+
+		resp, err := client.Do(req)
 		if err != nil {
-			log.Printf("Request to %s failed with: %v", url, err)
+			fmt.Fprintf(out, "Request to %s failed with: %v\n", url, err)
 			continue
 		}
-		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			fmt.Fprintf(out, "Failed to read response with: %v\n", err)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusNotModified && len(header) > 0 {
+			return resp, nil, nil
+		}
 
 		if resp.StatusCode != http.StatusOK {
-			_, _ = io.Copy(io.Discard, resp.Body)
-			log.Printf("API request failed with status: %d", resp.StatusCode)
+			fmt.Fprintf(out, "API request failed with status: %d\n", resp.StatusCode)
 			continue
 		}
 
-		var data USNsResponse
-		if err = json.NewDecoder(resp.Body).Decode(&data); err != nil {
-			log.Printf("Failed to decode JSON response with: %v", err)
+		if err = validate(body); err != nil {
+			fmt.Fprintf(out, "Failed to decode response with: %v\n", err)
 			continue
 		}
-		return data.Notices, nil
+		return resp, body, nil
 	}
-	return nil, fmt.Errorf("failed after %d attempts", maxRetries)
+	return nil, nil, fmt.Errorf("failed after %d attempts", maxRetries)
 }
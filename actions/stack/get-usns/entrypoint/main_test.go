@@ -1,12 +1,15 @@
 package main_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -32,6 +35,7 @@ func TestEntrypoint(t *testing.T) {
 			Eventually = NewWithT(t).Eventually
 
 			api            *httptest.Server
+			apiRequests    atomic.Int32
 			outputFilepath string
 		)
 
@@ -39,7 +43,10 @@ func TestEntrypoint(t *testing.T) {
 			tempDir := t.TempDir()
 			outputFilepath = filepath.Join(tempDir, "output-file")
 
+			apiRequests.Store(0)
 			api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				apiRequests.Add(1)
+
 				// Handle pagination based on offset query param
 				offset := req.URL.Query().Get("offset")
 
@@ -208,19 +215,256 @@ func TestEntrypoint(t *testing.T) {
 			})
 		})
 
-		context("failure cases", func() {
-			context("when the API returns a non-200 status", func() {
-				it("prints an error and exits non-zero", func() {
-					failingAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-						w.WriteHeader(http.StatusInternalServerError)
-					}))
-					defer failingAPI.Close()
+		context("pages are fetched automatically", func() {
+			it("stops at the first page with a last known USN", func() {
+				command := exec.Command(
+					entrypoint,
+					"--api-url", api.URL,
+					"--packages", `["avahi", "netty"]`,
+					"--distro", "noble",
+					"--last-usns", `[{"id":"USN-7919-1","title":"GNU binutils vulnerabilities","url":"","affected_packages":[],"cves":[]}]`,
+					"--pages", "auto",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(buffer).To(gbytes.Say(`Fetched 2 pages of notices`))
+				Expect(apiRequests.Load()).To(Equal(int32(2)))
+
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring(`"id":"USN-7967-1"`))
+				Expect(string(contents)).To(ContainSubstring(`"id":"USN-7918-1"`))
+			})
+
+			it("stops at the first page with a notice published before the cutoff", func() {
+				command := exec.Command(
+					entrypoint,
+					"--api-url", api.URL,
+					"--packages", `["avahi", "mame"]`,
+					"--distro", "noble",
+					"--pages", "auto",
+					"--cutoff", "2025-12-05",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(apiRequests.Load()).To(Equal(int32(2)))
+
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring(`"id":"USN-7967-1"`))
+				Expect(string(contents)).NotTo(ContainSubstring(`"id":"USN-7913-1"`))
+			})
+
+			it("stops at the most pages", func() {
+				command := exec.Command(
+					entrypoint,
+					"--api-url", api.URL,
+					"--packages", `["avahi"]`,
+					"--distro", "noble",
+					"--pages", "auto",
+					"--max-pages", "3",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(buffer).To(gbytes.Say(`Stopped after 3 pages without finding a last known USN, older notices may be missing`))
+				Expect(apiRequests.Load()).To(Equal(int32(3)))
+			})
+		})
+
+		context("pages are cached", func() {
+			var (
+				cachingAPI  *httptest.Server
+				revalidated atomic.Int32
+				cacheDir    string
+			)
+
+			it.Before(func() {
+				cacheDir = t.TempDir()
+
+				revalidated.Store(0)
+				cachingAPI = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					apiRequests.Add(1)
+
+					etag := fmt.Sprintf(`"notices-%s"`, req.URL.Query().Get("offset"))
+					if req.Header.Get("If-None-Match") == etag {
+						revalidated.Add(1)
+						w.WriteHeader(http.StatusNotModified)
+						return
+					}
+
+					data, err := os.ReadFile(filepath.Join("testdata", "notices0-20.json"))
+					if err != nil {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+
+					w.Header().Set("ETag", etag)
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					_, _ = w.Write(data)
+				}))
+			})
+
+			it.After(func() {
+				cachingAPI.Close()
+			})
+
+			it("serves fresh pages from the cache", func() {
+				command := exec.Command(
+					entrypoint,
+					"--api-url", cachingAPI.URL,
+					"--packages", `["avahi"]`,
+					"--distro", "noble",
+					"--cache-dir", cacheDir,
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(apiRequests.Load()).To(Equal(int32(1)))
+
+				command = exec.Command(
+					entrypoint,
+					"--api-url", cachingAPI.URL,
+					"--packages", `["avahi"]`,
+					"--distro", "noble",
+					"--cache-dir", cacheDir,
+					"--output", outputFilepath,
+				)
+
+				buffer = gbytes.NewBuffer()
+
+				session, err = gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(buffer).To(gbytes.Say(`Using cached notices for noble-0`))
+				Expect(apiRequests.Load()).To(Equal(int32(1)))
+
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`"id":"USN-7967-1"`))
+			})
+
+			it("revalidates expired pages", func() {
+				command := exec.Command(
+					entrypoint,
+					"--api-url", cachingAPI.URL,
+					"--packages", `["avahi"]`,
+					"--distro", "noble",
+					"--cache-dir", cacheDir,
+					"--cache-ttl", "0s",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+				command = exec.Command(
+					entrypoint,
+					"--api-url", cachingAPI.URL,
+					"--packages", `["avahi"]`,
+					"--distro", "noble",
+					"--cache-dir", cacheDir,
+					"--cache-ttl", "0s",
+					"--output", outputFilepath,
+				)
+
+				buffer = gbytes.NewBuffer()
+
+				session, err = gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(buffer).To(gbytes.Say(`Cached notices for noble-0 are still current`))
+				Expect(apiRequests.Load()).To(Equal(int32(2)))
+				Expect(revalidated.Load()).To(Equal(int32(1)))
 
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`"id":"USN-7967-1"`))
+			})
+
+			it("serves pages only from the cache when offline", func() {
+				command := exec.Command(
+					entrypoint,
+					"--api-url", cachingAPI.URL,
+					"--packages", `["avahi"]`,
+					"--distro", "noble",
+					"--cache-dir", cacheDir,
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+				cachingAPI.Close()
+
+				command = exec.Command(
+					entrypoint,
+					"--api-url", cachingAPI.URL,
+					"--packages", `["avahi"]`,
+					"--distro", "noble",
+					"--cache-dir", cacheDir,
+					"--cache-ttl", "0s",
+					"--offline",
+					"--output", outputFilepath,
+				)
+
+				buffer = gbytes.NewBuffer()
+
+				session, err = gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(apiRequests.Load()).To(Equal(int32(1)))
+
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`"id":"USN-7967-1"`))
+			})
+
+			context("when offline without a cached page", func() {
+				it("prints an error and exits non-zero", func() {
 					command := exec.Command(
 						entrypoint,
-						"--api-url", failingAPI.URL,
+						"--api-url", cachingAPI.URL,
 						"--packages", `["avahi"]`,
 						"--distro", "noble",
+						"--cache-dir", cacheDir,
+						"--offline",
 						"--output", outputFilepath,
 					)
 
@@ -229,8 +473,1182 @@ func TestEntrypoint(t *testing.T) {
 					session, err := gexec.Start(command, buffer, buffer)
 					Expect(err).NotTo(HaveOccurred())
 
-					Eventually(session, 20*time.Second).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
-					Expect(string(buffer.Contents())).To(ContainSubstring("API request failed with status: 500"))
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring("no cached notices for noble-0 in offline mode"))
+					Expect(apiRequests.Load()).To(Equal(int32(0)))
+				})
+			})
+		})
+
+		context("packages never appear in a fetched notice", func() {
+			it("warns about it", func() {
+				command := exec.Command(
+					entrypoint,
+					"--api-url", api.URL,
+					"--packages", `["avahi", "avahii"]`,
+					"--distro", "noble",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(string(buffer.Contents())).To(ContainSubstring("::warning::Packages that appear in no fetched notice or installed package, check the package list for a typo: avahii\n"))
+			})
+
+			it("warns about them once and leaves out installed packages", func() {
+				command := exec.Command(
+					entrypoint,
+					"--api-url", api.URL,
+					"--packages", `["avahi", "avahii", "libc6", "some-package"]`,
+					"--installed-versions", `{"libc6": "2.39-0ubuntu8"}`,
+					"--previous-versions", `{"libc6": "2.39-0ubuntu8"}`,
+					"--distro", "noble",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(string(buffer.Contents())).To(ContainSubstring("::warning::Packages that appear in no fetched notice or installed package, check the package list for a typo: avahii, some-package\n"))
+				Expect(strings.Count(string(buffer.Contents()), "::warning::")).To(Equal(1))
+			})
+		})
+
+		context("the source packages of packages are given", func() {
+			it("matches the notices of the source packages read from a dpkg status file", func() {
+				command := exec.Command(
+					entrypoint,
+					"--api-url", api.URL,
+					"--packages", `["libavahi-common3t64", "base-files"]`,
+					"--package-sources-filepath", "testdata/dpkg-status",
+					"--distro", "noble",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(buffer).To(gbytes.Say(`USN 'Avahi vulnerabilities' contains affected source package 'avahi' of package 'libavahi-common3t64'`))
+				Expect(string(buffer.Contents())).NotTo(ContainSubstring("Package 'libavahi-common3t64' does not appear"))
+
+				var usns []struct {
+					ID string `json:"id"`
+				}
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(json.Unmarshal(contents, &usns)).To(Succeed())
+
+				Expect(usns).To(HaveLen(1))
+				Expect(usns[0].ID).To(Equal("USN-7967-1"))
+			})
+
+			it("matches the notices of the source packages given as a JSON object", func() {
+				command := exec.Command(
+					entrypoint,
+					"--api-url", api.URL,
+					"--packages", `["libavahi-common3t64"]`,
+					"--package-sources", `{"libavahi-common3t64": "avahi"}`,
+					"--distro", "noble",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`"id":"USN-7967-1"`))
+			})
+		})
+
+		context("a publish date window is given", func() {
+			it("outputs the USNs published within the window", func() {
+				testdataPath, err := filepath.Abs("testdata/notices-with-priorities.json")
+				Expect(err).NotTo(HaveOccurred())
+
+				command := exec.Command(
+					entrypoint,
+					"--api-url", "file://"+testdataPath,
+					"--packages", `["avahi", "simgear", "gnupg", "libcaca"]`,
+					"--distro", "noble",
+					"--since", "2026-01-08",
+					"--until", "2026-01-15T14:45:00Z",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(buffer).To(gbytes.Say(`USN 'USN-7967-1' was published on 2026-01-19, outside of the date window, skipping it`))
+				Expect(buffer).To(gbytes.Say(`USN 'USN-7943-1' was published on 2026-01-07, outside of the date window, skipping it`))
+
+				var usns []struct {
+					ID string `json:"id"`
+				}
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(json.Unmarshal(contents, &usns)).To(Succeed())
+
+				Expect(usns).To(HaveLen(2))
+				Expect(usns[0].ID).To(Equal("USN-7965-1"))
+				Expect(usns[1].ID).To(Equal("USN-7946-1"))
+			})
+
+			it("fetches pages back to the start of the window without last known USNs", func() {
+				command := exec.Command(
+					entrypoint,
+					"--api-url", api.URL,
+					"--packages", `["avahi", "mame"]`,
+					"--distro", "noble",
+					"--pages", "auto",
+					"--since", "2025-12-05",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(apiRequests.Load()).To(Equal(int32(2)))
+
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring(`"id":"USN-7967-1"`))
+				Expect(string(contents)).NotTo(ContainSubstring(`"id":"USN-7913-1"`))
+			})
+
+			it("fetches pages automatically when no pages are given", func() {
+				command := exec.Command(
+					entrypoint,
+					"--api-url", api.URL,
+					"--packages", `["avahi", "mame"]`,
+					"--distro", "noble",
+					"--since", "2025-12-05",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(apiRequests.Load()).To(Equal(int32(2)))
+
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring(`"id":"USN-7967-1"`))
+			})
+		})
+
+		context("several distros are given", func() {
+			it("outputs the USNs of each distro keyed by its name", func() {
+				testdataPath, err := filepath.Abs("testdata/notices-with-priorities.json")
+				Expect(err).NotTo(HaveOccurred())
+
+				command := exec.Command(
+					entrypoint,
+					"--api-url", "file://"+testdataPath,
+					"--packages", `["avahi", "keyboxd"]`,
+					"--distros", "noble,jammy",
+					"--workers", "2",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+				var usns map[string][]struct {
+					ID               string   `json:"id"`
+					AffectedPackages []string `json:"affected_packages"`
+				}
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(json.Unmarshal(contents, &usns)).To(Succeed())
+
+				Expect(usns).To(HaveLen(2))
+				Expect(usns["noble"]).To(HaveLen(2))
+				Expect(usns["noble"][0].ID).To(Equal("USN-7967-1"))
+				Expect(usns["noble"][1].ID).To(Equal("USN-7946-1"))
+				Expect(usns["noble"][1].AffectedPackages).To(ContainElement("keyboxd"))
+				Expect(usns["jammy"]).To(HaveLen(1))
+				Expect(usns["jammy"][0].ID).To(Equal("USN-7967-1"))
+			})
+
+			it("prints the logs of each distro together", func() {
+				testdataPath, err := filepath.Abs("testdata/notices-with-priorities.json")
+				Expect(err).NotTo(HaveOccurred())
+
+				command := exec.Command(
+					entrypoint,
+					"--api-url", "file://"+testdataPath,
+					"--packages", `["avahi", "keyboxd"]`,
+					"--distros", "noble,jammy",
+					"--workers", "2",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+				// each distro logs a block that starts with its header and holds
+				// every line it logged, before the block of the next distro
+				var blocks [][]string
+				for _, line := range strings.Split(string(buffer.Contents()), "\n") {
+					if strings.HasPrefix(line, "Collecting USNs for ") {
+						blocks = append(blocks, nil)
+					}
+					if len(blocks) > 0 {
+						blocks[len(blocks)-1] = append(blocks[len(blocks)-1], line)
+					}
+				}
+
+				Expect(blocks).To(HaveLen(2))
+				Expect([]string{blocks[0][0], blocks[1][0]}).To(ConsistOf("Collecting USNs for noble...", "Collecting USNs for jammy..."))
+				for _, block := range blocks {
+					Expect(block).To(ContainElement("Recent USNs found:"))
+					Expect(block).To(ContainElement("Filtering USNs by affected packages..."))
+				}
+			})
+
+			it("reads the supported distros from the distros config", func() {
+				testdataPath, err := filepath.Abs("testdata/notices-with-priorities.json")
+				Expect(err).NotTo(HaveOccurred())
+
+				distrosConfig := filepath.Join(t.TempDir(), "distros.json")
+				Expect(os.WriteFile(distrosConfig, []byte(`[{"name": "plucky", "version": "25.04"}]`), os.ModePerm)).To(Succeed())
+
+				command := exec.Command(
+					entrypoint,
+					"--api-url", "file://"+testdataPath,
+					"--packages", `["keyboxd"]`,
+					"--distros", "plucky",
+					"--distros-config", distrosConfig,
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`"id":"USN-7946-1"`))
+				Expect(string(contents)).To(HavePrefix(`{"plucky":[`))
+			})
+		})
+
+		context("advisories come from the Debian Security Tracker", func() {
+			var trackerURL, advisoryLists string
+
+			it.Before(func() {
+				testdataPath, err := filepath.Abs("testdata/debian")
+				Expect(err).NotTo(HaveOccurred())
+
+				trackerURL = "file://" + filepath.Join(testdataPath, "tracker.json")
+				advisoryLists = "file://" + filepath.Join(testdataPath, "DSA-list") + ",file://" + filepath.Join(testdataPath, "DLA-list")
+			})
+
+			it("outputs the DSAs and DLAs newer than the last known ones", func() {
+				command := exec.Command(
+					entrypoint,
+					"--source", "debian",
+					"--api-url", trackerURL,
+					"--advisory-lists", advisoryLists,
+					"--packages", `["openssl", "curl", "libxml2", "glibc"]`,
+					"--last-usns", `[{"id": "DSA-6095-1"}]`,
+					"--distro", "bookworm",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+				var usns []struct {
+					ID               string   `json:"id"`
+					Title            string   `json:"title"`
+					URL              string   `json:"url"`
+					Priority         string   `json:"priority"`
+					AffectedPackages []string `json:"affected_packages"`
+					CVEs             []struct {
+						Title       string `json:"title"`
+						URL         string `json:"url"`
+						Priority    string `json:"priority"`
+						Description string `json:"description"`
+					} `json:"cves"`
+				}
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(json.Unmarshal(contents, &usns)).To(Succeed())
+
+				Expect(usns).To(HaveLen(1))
+				Expect(usns[0].ID).To(Equal("DSA-6101-1"))
+				Expect(usns[0].Title).To(Equal("DSA-6101-1: openssl - security update"))
+				Expect(usns[0].URL).To(Equal("https://security-tracker.debian.org/tracker/DSA-6101-1"))
+				Expect(usns[0].Priority).To(Equal("medium"))
+				Expect(usns[0].AffectedPackages).To(Equal([]string{"openssl"}))
+				Expect(usns[0].CVEs).To(HaveLen(2))
+				Expect(usns[0].CVEs[0].Title).To(Equal("CVE-2025-9230"))
+				Expect(usns[0].CVEs[0].URL).To(Equal("https://security-tracker.debian.org/tracker/CVE-2025-9230"))
+				Expect(usns[0].CVEs[0].Priority).To(Equal("medium"))
+				Expect(usns[0].CVEs[0].Description).To(Equal("Out-of-bounds read & write in RFC 3211 KEK Unwrap"))
+				Expect(usns[0].CVEs[1].Priority).To(Equal("negligible"))
+			})
+
+			it("does not read the tracker without --api-url or --min-priority", func() {
+				command := exec.Command(
+					entrypoint,
+					"--source", "debian",
+					"--advisory-lists", advisoryLists,
+					"--packages", `["openssl"]`,
+					"--last-usns", `[{"id": "DSA-6095-1"}]`,
+					"--distro", "bookworm",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+				var usns []struct {
+					ID   string `json:"id"`
+					CVEs []struct {
+						Title       string `json:"title"`
+						Priority    string `json:"priority"`
+						Description string `json:"description"`
+					} `json:"cves"`
+				}
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(json.Unmarshal(contents, &usns)).To(Succeed())
+
+				Expect(usns).To(HaveLen(1))
+				Expect(usns[0].ID).To(Equal("DSA-6101-1"))
+				Expect(usns[0].CVEs).To(HaveLen(2))
+				Expect(usns[0].CVEs[0].Title).To(Equal("CVE-2025-9230"))
+				Expect(usns[0].CVEs[0].Priority).To(BeEmpty())
+				Expect(usns[0].CVEs[0].Description).To(BeEmpty())
+			})
+
+			it("outputs the advisories of each suite in OSV records", func() {
+				osvFilepath := filepath.Join(t.TempDir(), "osv.json")
+
+				command := exec.Command(
+					entrypoint,
+					"--source", "debian",
+					"--api-url", trackerURL,
+					"--advisory-lists", advisoryLists,
+					"--packages", `["openssl"]`,
+					"--distros", "bookworm,bullseye",
+					"--output", outputFilepath,
+					"--osv-output", osvFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+				var usns map[string][]struct {
+					ID string `json:"id"`
+				}
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(json.Unmarshal(contents, &usns)).To(Succeed())
+
+				Expect(usns["bookworm"]).To(HaveLen(1))
+				Expect(usns["bookworm"][0].ID).To(Equal("DSA-6101-1"))
+				Expect(usns["bullseye"]).To(HaveLen(1))
+				Expect(usns["bullseye"][0].ID).To(Equal("DLA-4420-1"))
+
+				var records []struct {
+					ID       string `json:"id"`
+					Affected []struct {
+						Package struct {
+							Ecosystem string `json:"ecosystem"`
+							PURL      string `json:"purl"`
+						} `json:"package"`
+						Ranges []struct {
+							Events []map[string]string `json:"events"`
+						} `json:"ranges"`
+					} `json:"affected"`
+				}
+				contents, err = os.ReadFile(osvFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(json.Unmarshal(contents, &records)).To(Succeed())
+
+				Expect(records).To(HaveLen(2))
+				Expect(records[0].ID).To(Equal("DSA-6101-1"))
+				Expect(records[0].Affected[0].Package.Ecosystem).To(Equal("Debian:12"))
+				Expect(records[0].Affected[0].Package.PURL).To(Equal("pkg:deb/debian/openssl?distro=debian-12"))
+				Expect(records[0].Affected[0].Ranges[0].Events).To(Equal([]map[string]string{{"introduced": "0"}, {"fixed": "3.0.18-1~deb12u1"}}))
+				Expect(records[1].ID).To(Equal("DLA-4420-1"))
+				Expect(records[1].Affected[0].Package.Ecosystem).To(Equal("Debian:11"))
+			})
+
+			context("the lists and tracker are cached", func() {
+				var (
+					trackerAPI *httptest.Server
+					cacheDir   string
+				)
+
+				it.Before(func() {
+					cacheDir = t.TempDir()
+
+					trackerAPI = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						apiRequests.Add(1)
+
+						data, err := os.ReadFile(filepath.Join("testdata", "debian", filepath.Base(req.URL.Path)))
+						if err != nil {
+							w.WriteHeader(http.StatusNotFound)
+							return
+						}
+
+						w.WriteHeader(http.StatusOK)
+						_, _ = w.Write(data)
+					}))
+				})
+
+				it.After(func() {
+					trackerAPI.Close()
+				})
+
+				it("serves them only from the cache when offline", func() {
+					args := []string{
+						"--source", "debian",
+						"--api-url", trackerAPI.URL + "/tracker.json",
+						"--advisory-lists", trackerAPI.URL + "/DSA-list," + trackerAPI.URL + "/DLA-list",
+						"--packages", `["openssl"]`,
+						"--distro", "bookworm",
+						"--cache-dir", cacheDir,
+						"--output", outputFilepath,
+					}
+
+					command := exec.Command(entrypoint, args...)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(apiRequests.Load()).To(Equal(int32(3)))
+
+					trackerAPI.Close()
+					Expect(os.Remove(outputFilepath)).To(Succeed())
+
+					command = exec.Command(entrypoint, append(args, "--offline")...)
+
+					buffer = gbytes.NewBuffer()
+
+					session, err = gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(apiRequests.Load()).To(Equal(int32(3)))
+
+					var usns []struct {
+						ID   string `json:"id"`
+						CVEs []struct {
+							Description string `json:"description"`
+						} `json:"cves"`
+					}
+					contents, err := os.ReadFile(outputFilepath)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(json.Unmarshal(contents, &usns)).To(Succeed())
+
+					Expect(usns).To(HaveLen(1))
+					Expect(usns[0].ID).To(Equal("DSA-6101-1"))
+					Expect(usns[0].CVEs[0].Description).To(Equal("Out-of-bounds read & write in RFC 3211 KEK Unwrap"))
+				})
+
+				context("when offline without a cached list", func() {
+					it("prints an error and exits non-zero", func() {
+						command := exec.Command(
+							entrypoint,
+							"--source", "debian",
+							"--api-url", trackerAPI.URL+"/tracker.json",
+							"--advisory-lists", trackerAPI.URL+"/DSA-list",
+							"--packages", `["openssl"]`,
+							"--distro", "bookworm",
+							"--cache-dir", cacheDir,
+							"--offline",
+							"--output", outputFilepath,
+						)
+
+						buffer := gbytes.NewBuffer()
+
+						session, err := gexec.Start(command, buffer, buffer)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+						Expect(string(buffer.Contents())).To(ContainSubstring("no cached notices for debian-advisories-0 in offline mode"))
+						Expect(apiRequests.Load()).To(Equal(int32(0)))
+					})
+				})
+			})
+
+			context("a list holds more advisories than the pages to fetch", func() {
+				var longList string
+
+				it.Before(func() {
+					var list strings.Builder
+					for i := 0; i < 25; i++ {
+						fmt.Fprintf(&list, "[14 Jan 2026] DSA-%d-1 openssl - security update\n", 6200-i)
+						fmt.Fprintf(&list, "\t[bookworm] - openssl 3.0.18-1~deb12u%d\n", 25-i)
+					}
+
+					longList = filepath.Join(t.TempDir(), "DSA-list")
+					Expect(os.WriteFile(longList, []byte(list.String()), 0644)).To(Succeed())
+				})
+
+				it("reads a page of advisories per page", func() {
+					command := exec.Command(
+						entrypoint,
+						"--source", "debian",
+						"--api-url", trackerURL,
+						"--advisory-lists", "file://"+longList,
+						"--packages", `["openssl"]`,
+						"--distro", "bookworm",
+						"--pages", "1",
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+					var usns []struct {
+						ID string `json:"id"`
+					}
+					contents, err := os.ReadFile(outputFilepath)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(json.Unmarshal(contents, &usns)).To(Succeed())
+
+					Expect(usns).To(HaveLen(20))
+					Expect(usns[0].ID).To(Equal("DSA-6200-1"))
+					Expect(usns[19].ID).To(Equal("DSA-6181-1"))
+				})
+
+				it("reads up to the most pages with auto paging and says that older advisories may be missing", func() {
+					command := exec.Command(
+						entrypoint,
+						"--source", "debian",
+						"--api-url", trackerURL,
+						"--advisory-lists", "file://"+longList,
+						"--packages", `["openssl"]`,
+						"--distro", "bookworm",
+						"--pages", "auto",
+						"--max-pages", "1",
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(buffer).To(gbytes.Say(`Stopped after 20 advisories of file://.*DSA-list without finding a last known advisory, older advisories may be missing`))
+
+					var usns []struct {
+						ID string `json:"id"`
+					}
+					contents, err := os.ReadFile(outputFilepath)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(json.Unmarshal(contents, &usns)).To(Succeed())
+
+					Expect(usns).To(HaveLen(20))
+				})
+			})
+		})
+
+		context("a minimum priority is given", func() {
+			it("outputs the USNs with a CVE of at least that priority, along with their CVE details", func() {
+				testdataPath, err := filepath.Abs("testdata/notices-with-priorities.json")
+				Expect(err).NotTo(HaveOccurred())
+
+				command := exec.Command(
+					entrypoint,
+					"--api-url", "file://"+testdataPath,
+					"--packages", `["avahi", "simgear", "gnupg", "libcaca"]`,
+					"--distro", "noble",
+					"--min-priority", "medium",
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(buffer).To(gbytes.Say(`USN 'USN-7965-1' has a highest CVE priority of negligible, skipping it`))
+				Expect(buffer).To(gbytes.Say(`USN 'USN-7943-1' has no CVE with a known priority, keeping it`))
+
+				var usns []struct {
+					ID       string `json:"id"`
+					Priority string `json:"priority"`
+					CVEs     []struct {
+						Title       string   `json:"title"`
+						Priority    string   `json:"priority"`
+						CVSSScore   *float64 `json:"cvss_score"`
+						Description string   `json:"description"`
+					} `json:"cves"`
+				}
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(json.Unmarshal(contents, &usns)).To(Succeed())
+
+				Expect(usns).To(HaveLen(3))
+				Expect(usns[0].ID).To(Equal("USN-7967-1"))
+				Expect(usns[0].Priority).To(Equal("high"))
+				Expect(usns[0].CVEs[0].Priority).To(Equal("high"))
+				Expect(*usns[0].CVEs[0].CVSSScore).To(Equal(7.5))
+				Expect(usns[0].CVEs[0].Description).To(Equal("A flaw was found in Avahi."))
+				Expect(usns[0].CVEs[1].CVSSScore).To(BeNil())
+				Expect(usns[1].ID).To(Equal("USN-7946-1"))
+				Expect(usns[1].Priority).To(Equal("medium"))
+				Expect(usns[2].ID).To(Equal("USN-7943-1"))
+				Expect(usns[2].Priority).To(BeEmpty())
+			})
+		})
+
+		context("OSV and OpenVEX outputs are requested", func() {
+			it("outputs the USNs in those formats as well", func() {
+				osvFilepath := filepath.Join(t.TempDir(), "osv.json")
+				openVEXFilepath := filepath.Join(t.TempDir(), "openvex.json")
+
+				command := exec.Command(
+					entrypoint,
+					"--api-url", api.URL,
+					"--packages", `["avahi-daemon"]`,
+					"--distro", "noble",
+					"--output", outputFilepath,
+					"--osv-output", osvFilepath,
+					"--openvex-output", openVEXFilepath,
+					"--openvex-product", "pkg:oci/run-noble-base?repository_url=index.docker.io/paketobuildpacks/run-noble-base",
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+				var records []struct {
+					SchemaVersion string   `json:"schema_version"`
+					ID            string   `json:"id"`
+					Published     string   `json:"published"`
+					Upstream      []string `json:"upstream"`
+					Affected      []struct {
+						Package struct {
+							Ecosystem string `json:"ecosystem"`
+							Name      string `json:"name"`
+							PURL      string `json:"purl"`
+						} `json:"package"`
+						Ranges []struct {
+							Type   string              `json:"type"`
+							Events []map[string]string `json:"events"`
+						} `json:"ranges"`
+					} `json:"affected"`
+				}
+				contents, err := os.ReadFile(osvFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(json.Unmarshal(contents, &records)).To(Succeed())
+
+				Expect(records).To(HaveLen(1))
+				Expect(records[0].SchemaVersion).To(Equal("1.6.0"))
+				Expect(records[0].ID).To(Equal("USN-7967-1"))
+				Expect(records[0].Published).To(Equal("2026-01-19T12:30:55Z"))
+				Expect(records[0].Upstream).NotTo(BeEmpty())
+				Expect(records[0].Affected[0].Package.Ecosystem).To(Equal("Ubuntu:24.04:LTS"))
+				Expect(records[0].Affected[0].Package.Name).To(Equal("avahi"))
+				Expect(records[0].Affected[0].Package.PURL).To(Equal("pkg:deb/ubuntu/avahi?distro=ubuntu-24.04"))
+				Expect(records[0].Affected[0].Ranges[0].Type).To(Equal("ECOSYSTEM"))
+				Expect(records[0].Affected[0].Ranges[0].Events).To(Equal([]map[string]string{{"introduced": "0"}, {"fixed": "0.8-13ubuntu6.1"}}))
+
+				var document struct {
+					Context    string `json:"@context"`
+					ID         string `json:"@id"`
+					Author     string `json:"author"`
+					Statements []struct {
+						Vulnerability struct {
+							Name    string   `json:"name"`
+							Aliases []string `json:"aliases"`
+						} `json:"vulnerability"`
+						Products []struct {
+							ID            string              `json:"@id"`
+							Subcomponents []map[string]string `json:"subcomponents"`
+						} `json:"products"`
+						Status string `json:"status"`
+					} `json:"statements"`
+				}
+				contents, err = os.ReadFile(openVEXFilepath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(json.Unmarshal(contents, &document)).To(Succeed())
+
+				Expect(document.Context).To(Equal("https://openvex.dev/ns/v0.2.0"))
+				Expect(document.ID).To(HavePrefix("https://openvex.dev/docs/public/vex-"))
+				Expect(document.Author).To(Equal("Paketo Buildpacks"))
+				Expect(document.Statements).To(HaveLen(1))
+				Expect(document.Statements[0].Vulnerability.Name).To(Equal("USN-7967-1"))
+				Expect(document.Statements[0].Vulnerability.Aliases).To(Equal(records[0].Upstream))
+				Expect(document.Statements[0].Status).To(Equal("fixed"))
+				Expect(document.Statements[0].Products[0].ID).To(Equal("pkg:oci/run-noble-base?repository_url=index.docker.io/paketobuildpacks/run-noble-base"))
+				Expect(document.Statements[0].Products[0].Subcomponents).To(Equal([]map[string]string{{"@id": "pkg:deb/ubuntu/avahi-daemon@0.8-13ubuntu6.1?distro=ubuntu-24.04"}}))
+			})
+		})
+
+		context("installed versions are given", func() {
+			it("outputs only the USNs fixed since the previous versions", func() {
+				command := exec.Command(
+					entrypoint,
+					"--api-url", api.URL,
+					"--packages", `["avahi-daemon", "simgear", "libcaca0"]`,
+					"--distro", "noble",
+					"--installed-versions", `{"avahi-daemon": "0.8-13ubuntu6.1", "simgear": "1:2020.3.18+dfsg-2.1ubuntu0.1", "libcaca0": "0.99.beta20-4"}`,
+					"--previous-versions", `{"avahi-daemon": "0.8-13ubuntu6", "simgear": "1:2020.3.18+dfsg-2.1ubuntu0.1", "libcaca0": "0.99.beta20-4"}`,
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(buffer).To(gbytes.Say(`USN 'USN-7967-1' is fixed-in-this-release`))
+				Expect(buffer).To(gbytes.Say(`USN 'USN-7965-1' is already-fixed`))
+				Expect(buffer).To(gbytes.Say(`USN 'USN-7943-1' is still-vulnerable`))
+
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring(`"id":"USN-7967-1","url":"https://ubuntu.com/security/notices/USN-7967-1","status":"fixed-in-this-release"`))
+				Expect(string(contents)).NotTo(ContainSubstring(`"id":"USN-7965-1"`))
+				Expect(string(contents)).NotTo(ContainSubstring(`"id":"USN-7943-1"`))
+			})
+
+			it("reads the installed versions from a CycloneDX receipt", func() {
+				receipt := filepath.Join(t.TempDir(), "receipt.cdx.json")
+				Expect(os.WriteFile(receipt, []byte(`{
+					"bomFormat": "CycloneDX",
+					"components": [
+						{"name": "avahi-daemon", "version": "0.8-13ubuntu6.1", "purl": "pkg:deb/ubuntu/avahi-daemon@0.8-13ubuntu6.1?arch=amd64"},
+						{"name": "libcaca0", "version": "0.99.beta20-4ubuntu0.2", "purl": "pkg:deb/ubuntu/libcaca0@0.99.beta20-4ubuntu0.2?arch=amd64"}
+					]
+				}`), 0600)).To(Succeed())
+
+				command := exec.Command(
+					entrypoint,
+					"--api-url", api.URL,
+					"--distro", "noble",
+					"--installed-versions-filepath", receipt,
+					"--previous-versions", `{"avahi-daemon": "0.8-13ubuntu6", "libcaca0": "0.99.beta20-4ubuntu0.2"}`,
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(buffer).To(gbytes.Say(`USN 'USN-7967-1' is fixed-in-this-release`))
+				Expect(buffer).To(gbytes.Say(`USN 'USN-7943-1' is already-fixed`))
+
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring(`"id":"USN-7967-1","url":"https://ubuntu.com/security/notices/USN-7967-1","status":"fixed-in-this-release"`))
+				Expect(string(contents)).NotTo(ContainSubstring(`"id":"USN-7943-1"`))
+				Expect(string(contents)).NotTo(ContainSubstring(`"id":"USN-7965-1"`))
+			})
+
+			it("compares the versions of the binary packages of the source packages in USNs", func() {
+				command := exec.Command(
+					entrypoint,
+					"--api-url", api.URL,
+					"--packages", `["libavahi-common3t64"]`,
+					"--package-sources", `{"libavahi-common3t64": "avahi"}`,
+					"--distro", "noble",
+					"--installed-versions", `{"libavahi-common3t64": "0.8-13ubuntu6.1"}`,
+					"--previous-versions", `{"libavahi-common3t64": "0.8-13ubuntu6"}`,
+					"--output", outputFilepath,
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+				Expect(buffer).To(gbytes.Say(`USN 'USN-7967-1' is fixed-in-this-release`))
+
+				contents, err := os.ReadFile(outputFilepath)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring(`"id":"USN-7967-1","url":"https://ubuntu.com/security/notices/USN-7967-1","status":"fixed-in-this-release"`))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the API returns a non-200 status", func() {
+				it("prints an error and exits non-zero", func() {
+					failingAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						w.WriteHeader(http.StatusInternalServerError)
+					}))
+					defer failingAPI.Close()
+
+					command := exec.Command(
+						entrypoint,
+						"--api-url", failingAPI.URL,
+						"--packages", `["avahi"]`,
+						"--distro", "noble",
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session, 20*time.Second).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring("API request failed with status: 500"))
+				})
+			})
+
+			context("when installed versions are given without previous versions", func() {
+				it("prints an error and exits non-zero", func() {
+					command := exec.Command(
+						entrypoint,
+						"--api-url", api.URL,
+						"--distro", "noble",
+						"--installed-versions", `{"avahi-daemon": "0.8-13ubuntu6.1"}`,
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring("--installed-versions requires --previous-versions"))
+				})
+			})
+
+			context("when an installed version is not a Debian version", func() {
+				it("prints an error and exits non-zero", func() {
+					command := exec.Command(
+						entrypoint,
+						"--api-url", api.URL,
+						"--distro", "noble",
+						"--installed-versions", `{"avahi-daemon": "latest"}`,
+						"--previous-versions", `{"avahi-daemon": "0.8-13ubuntu6"}`,
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring(`failed to compare versions of avahi-daemon in USN-7967-1: invalid Debian version "latest": upstream version must start with a digit`))
+				})
+			})
+
+			context("when the pages flag is invalid", func() {
+				it("prints an error and exits non-zero", func() {
+					command := exec.Command(
+						entrypoint,
+						"--api-url", api.URL,
+						"--distro", "noble",
+						"--pages", "some-pages",
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring(`--pages must be at least 1 or auto, got "some-pages"`))
+				})
+			})
+
+			context("when an OpenVEX output is requested without a product", func() {
+				it("prints an error and exits non-zero", func() {
+					command := exec.Command(
+						entrypoint,
+						"--api-url", api.URL,
+						"--distro", "noble",
+						"--output", outputFilepath,
+						"--openvex-output", filepath.Join(t.TempDir(), "openvex.json"),
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring("--openvex-output requires --openvex-product"))
+				})
+			})
+
+			context("when the minimum priority is invalid", func() {
+				it("prints an error and exits non-zero", func() {
+					command := exec.Command(
+						entrypoint,
+						"--api-url", api.URL,
+						"--distro", "noble",
+						"--min-priority", "urgent",
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring("--min-priority has to be one of the following values: [negligible low medium high critical]"))
+				})
+			})
+
+			context("when both the inline and file packages are given", func() {
+				it("prints an error and exits non-zero", func() {
+					command := exec.Command(
+						entrypoint,
+						"--api-url", api.URL,
+						"--packages", `["avahi"]`,
+						"--packages-filepath", "testdata/amd64-package-list-jammy-tiny.json",
+						"--distro", "jammy",
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring("--packages and --packages-filepath cannot both be given"))
+				})
+			})
+
+			context("when a package is not a name", func() {
+				it("prints an error naming the item and exits non-zero", func() {
+					command := exec.Command(
+						entrypoint,
+						"--api-url", api.URL,
+						"--packages", `["avahi", 42]`,
+						"--distro", "noble",
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring("invalid --packages: item at index 1 must be a package name, got 42"))
+				})
+			})
+
+			context("when a last known USN has no id", func() {
+				it("prints an error naming the item and exits non-zero", func() {
+					lastUSNs := filepath.Join(t.TempDir(), "last-usns.json")
+					Expect(os.WriteFile(lastUSNs, []byte(`[{"id": "USN-7967-1"}, {"title": "USN-7965-1: SimGear vulnerability"}]`), os.ModePerm)).To(Succeed())
+
+					command := exec.Command(
+						entrypoint,
+						"--api-url", api.URL,
+						"--last-usns-filepath", lastUSNs,
+						"--distro", "noble",
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring(fmt.Sprintf(`invalid --last-usns-filepath %s: item at index 1 must have an id, got {"title": "USN-7965-1: SimGear vulnerability"}`, lastUSNs)))
+				})
+			})
+
+			context("when a source package is empty", func() {
+				it("prints an error and exits non-zero", func() {
+					command := exec.Command(
+						entrypoint,
+						"--api-url", api.URL,
+						"--packages", `["libavahi-common3t64"]`,
+						"--package-sources", `{"libavahi-common3t64": ""}`,
+						"--distro", "noble",
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring(`invalid --package-sources: source package of "libavahi-common3t64" must not be empty`))
+				})
+			})
+
+			context("when --since is given with a number of pages", func() {
+				it("prints an error and exits non-zero", func() {
+					command := exec.Command(
+						entrypoint,
+						"--api-url", api.URL,
+						"--distro", "noble",
+						"--pages", "2",
+						"--since", "2026-01-08",
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring("--since requires --pages auto"))
+				})
+			})
+
+			context("when the date window is empty", func() {
+				it("prints an error and exits non-zero", func() {
+					command := exec.Command(
+						entrypoint,
+						"--api-url", api.URL,
+						"--distro", "noble",
+						"--since", "2026-01-15",
+						"--until", "2026-01-08",
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring("--since must be before --until"))
+				})
+			})
+
+			context("when the source is not supported", func() {
+				it("prints an error and exits non-zero", func() {
+					command := exec.Command(
+						entrypoint,
+						"--source", "fedora",
+						"--distro", "noble",
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring(`--source has to be one of the following values: [ubuntu debian], got "fedora"`))
+				})
+			})
+
+			context("when one of the distros is not supported", func() {
+				it("prints an error and exits non-zero", func() {
+					command := exec.Command(
+						entrypoint,
+						"--api-url", api.URL,
+						"--packages", `["avahi"]`,
+						"--distros", "noble,invalid-distro",
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring("--distro flag has to be one of the following values: [noble jammy focal bionic resolute]"))
+				})
+			})
+
+			context("when both the distro and distros flags are given", func() {
+				it("prints an error and exits non-zero", func() {
+					command := exec.Command(
+						entrypoint,
+						"--api-url", api.URL,
+						"--packages", `["avahi"]`,
+						"--distro", "noble",
+						"--distros", "noble,jammy",
+						"--output", outputFilepath,
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+					Expect(string(buffer.Contents())).To(ContainSubstring("--distro and --distros cannot both be given"))
 				})
 			})
 
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+// priorities are the Ubuntu CVE priorities, lowest first, see
+// https://ubuntu.com/security/cves/about#priority.
+var priorities = []string{
+	"negligible",
+	"low",
+	"medium",
+	"high",
+	"critical",
+}
+
+// priorityRank returns the position of the priority in priorities, or -1
+// when the priority is unknown, such as for untriaged CVEs.
+func priorityRank(priority string) int {
+	return slices.Index(priorities, priority)
+}
+
+// highestPriority returns the highest priority of the CVEs of the USN, or an
+// empty string when none of them have a known priority.
+func highestPriority(usn USN) string {
+	highest := -1
+	for _, cve := range usn.CVEs {
+		highest = max(highest, priorityRank(cve.Priority))
+	}
+
+	if highest < 0 {
+		return ""
+	}
+
+	return priorities[highest]
+}
+
+// filterUSNsByPriority keeps the USNs with a CVE of at least the minimum
+// priority. USNs whose CVEs have no known priority are kept, so that notices
+// are not skipped before they have been triaged.
+func filterUSNsByPriority(usns []USN, minPriority string, out io.Writer) []USN {
+	fmt.Fprintf(out, "Filtering USNs by a minimum CVE priority of %s...\n", minPriority)
+
+	var filtered []USN
+	for _, usn := range usns {
+		priority := highestPriority(usn)
+		switch {
+		case priority == "":
+			fmt.Fprintf(out, "USN '%s' has no CVE with a known priority, keeping it\n", usn.ID)
+		case priorityRank(priority) < priorityRank(minPriority):
+			fmt.Fprintf(out, "USN '%s' has a highest CVE priority of %s, skipping it\n", usn.ID, priority)
+			continue
+		}
+
+		filtered = append(filtered, usn)
+	}
+
+	return filtered
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// advisorySource is a feed of security advisories, such as Ubuntu USNs or
+// Debian DSAs and DLAs. Each source returns its advisories as USNs, with
+// their packages keyed by distro, so that they are filtered and output the
+// same way.
+type advisorySource interface {
+	// fetch returns the advisories for the distro that are newer than the
+	// last known ones, logging its progress to out.
+	fetch(distro Distro, out io.Writer) ([]USN, error)
+
+	// noticeURL returns the URL of the page of an advisory.
+	noticeURL(id string) string
+
+	// cveURL returns the URL of the page of a CVE.
+	cveURL(id string) string
+
+	// ecosystem returns the OSV ecosystem of the packages of the distro.
+	ecosystem(distro Distro) string
+
+	// purl returns the package URL of a package of the distro.
+	purl(name, version string, distro Distro) string
+}
+
+// ubuntuSource fetches USNs from the Ubuntu security notices JSON API, or a
+// local JSON file of notices.
+type ubuntuSource struct {
+	apiURL string
+	known  []PatchedUsnsInputOutput
+	paging pageOptions
+	cache  *usnCache
+}
+
+func (s ubuntuSource) fetch(distro Distro, out io.Writer) ([]USN, error) {
+	if path, ok := fileURLPath(s.apiURL); ok {
+		return getNewUSNsFromFilepath(path)
+	}
+
+	return getNewUSNsFromJSONApi(s.apiURL, s.known, distro.Name, s.paging, s.cache, out)
+}
+
+func (s ubuntuSource) noticeURL(id string) string {
+	return fmt.Sprintf("https://ubuntu.com/security/notices/%s", id)
+}
+
+func (s ubuntuSource) cveURL(id string) string {
+	return fmt.Sprintf("https://ubuntu.com/security/%s", id)
+}
+
+func (s ubuntuSource) ecosystem(distro Distro) string {
+	return fmt.Sprintf("Ubuntu:%s:LTS", distro.Version)
+}
+
+func (s ubuntuSource) purl(name, version string, distro Distro) string {
+	return debPURL("ubuntu", name, version, distro.Version)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// parseDpkgStatus returns the source package of each binary package in a
+// dpkg status file, such as /var/lib/dpkg/status. A package without a Source
+// field is built from the source package of the same name.
+func parseDpkgStatus(content []byte) (map[string]string, error) {
+	sources := map[string]string{}
+
+	var name, source string
+	add := func() {
+		if name != "" {
+			if source == "" {
+				source = name
+			}
+			sources[name] = source
+		}
+		name, source = "", ""
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			add()
+			continue
+		}
+
+		field, value, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(line, " ") {
+			continue
+		}
+
+		switch field {
+		case "Package":
+			name = strings.TrimSpace(value)
+		case "Source":
+			// the source version is given in parentheses when it differs
+			// from the version of the binary package
+			source, _, _ = strings.Cut(strings.TrimSpace(value), " ")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	add()
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no packages found")
+	}
+
+	return sources, nil
+}
+
+// parsePackageSources reads the source package of each binary package from
+// either a JSON object of binary package names to source package names or a
+// dpkg status file.
+func parsePackageSources(content []byte) (map[string]string, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		sources, err := parseDpkgStatus(content)
+		if err != nil {
+			return nil, fmt.Errorf("must be a dpkg status file or a JSON object of binary package names to source package names: %w", err)
+		}
+
+		return sources, nil
+	}
+
+	sources := map[string]string{}
+	err := json.Unmarshal(content, &sources)
+	if err != nil {
+		return nil, fmt.Errorf("must be a dpkg status file or a JSON object of binary package names to source package names: %w", err)
+	}
+
+	for binary, source := range sources {
+		if source == "" {
+			return nil, fmt.Errorf("source package of %q must not be empty", binary)
+		}
+	}
+
+	return sources, nil
+}
+
+// loadPackageSources reads the source packages given by --package-sources or
+// --package-sources-filepath, returning nil when neither is given.
+func loadPackageSources(inline, path string) (map[string]string, error) {
+	content, origin, err := readInput("package-sources", inline, path)
+	if err != nil || content == nil {
+		return nil, err
+	}
+
+	sources, err := parsePackageSources(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", origin, err)
+	}
+
+	return sources, nil
+}
+
+// packageMatcher matches the packages of notices, which may be binary or
+// source packages, with the listed packages by either their own names or the
+// names of their source packages.
+type packageMatcher struct {
+	names map[string]string
+}
+
+func newPackageMatcher(packages []string, sources map[string]string) packageMatcher {
+	m := packageMatcher{names: map[string]string{}}
+	for _, pkg := range packages {
+		if source, ok := sources[pkg]; ok {
+			if _, ok := m.names[source]; !ok {
+				m.names[source] = pkg
+			}
+		}
+	}
+
+	// a listed package that is also the source of another listed package
+	// matches as itself
+	for _, pkg := range packages {
+		m.names[pkg] = pkg
+	}
+
+	return m
+}
+
+// match returns the listed package that the package of a notice matches.
+func (m packageMatcher) match(name string) (string, bool) {
+	pkg, ok := m.names[name]
+	return pkg, ok
+}
+
+func (m packageMatcher) empty() bool {
+	return len(m.names) == 0
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/paketo-buildpacks/github-config/internal/debversion"
+)
+
+const (
+	// StatusFixedInThisRelease means the stack picked up the fixed version of
+	// a package since its previous release
+	StatusFixedInThisRelease = "fixed-in-this-release"
+
+	// StatusAlreadyFixed means the stack already had the fixed version, or a
+	// newer one, before this release
+	StatusAlreadyFixed = "already-fixed"
+
+	// StatusStillVulnerable means the stack has a version of a package older
+	// than the fixed version
+	StatusStillVulnerable = "still-vulnerable"
+)
+
+// parsePackageVersions reads the versions of installed packages from either a
+// CycloneDX receipt or a JSON object of package names to versions.
+func parsePackageVersions(content []byte) (map[string]string, error) {
+	var receipt struct {
+		Components *[]struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"components"`
+	}
+	err := json.Unmarshal(content, &receipt)
+	if err == nil && receipt.Components != nil {
+		versions := map[string]string{}
+		for _, component := range *receipt.Components {
+			versions[component.Name] = component.Version
+		}
+
+		return versions, nil
+	}
+
+	versions := map[string]string{}
+	err = json.Unmarshal(content, &versions)
+	if err != nil {
+		return nil, fmt.Errorf("must be a CycloneDX receipt or a JSON object of package names to versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// loadPackageVersions reads package versions given by the named flag either
+// inline or as a file, returning nil when neither is given.
+func loadPackageVersions(name, inline, path string) (map[string]string, error) {
+	content, origin, err := readInput(name, inline, path)
+	if err != nil || content == nil {
+		return nil, err
+	}
+
+	versions, err := parsePackageVersions(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", origin, err)
+	}
+
+	return versions, nil
+}
+
+// statusPrecedence orders the statuses of the packages of a USN, the USN
+// taking the status of its package that comes first.
+var statusPrecedence = map[string]int{
+	StatusFixedInThisRelease: 2,
+	StatusAlreadyFixed:       1,
+}
+
+// classifyUSN compares the installed version of each package in the USN with
+// the version that fixed it. A package of the USN that is not installed by its
+// own name is the source package of the installed binary packages built from
+// it, given by binaries. The previous versions are those of the stack's last
+// release. It returns an empty status when none of the packages of the USN are
+// installed.
+func classifyUSN(usn USN, distro string, installed, previous map[string]string, binaries map[string][]string) (string, error) {
+	status := ""
+	for _, pkg := range usn.ReleasePackages[distro] {
+		names := []string{pkg.Name}
+		if _, ok := installed[pkg.Name]; !ok {
+			names = binaries[pkg.Name]
+		}
+
+		for _, name := range names {
+			version, ok := installed[name]
+			if !ok {
+				continue
+			}
+
+			result, err := debversion.Compare(version, pkg.Version)
+			if err != nil {
+				return "", fmt.Errorf("failed to compare versions of %s in %s: %w", name, usn.ID, err)
+			}
+
+			if result < 0 {
+				return StatusStillVulnerable, nil
+			}
+
+			packageStatus := StatusAlreadyFixed
+			if previousVersion, ok := previous[name]; ok {
+				result, err = debversion.Compare(previousVersion, pkg.Version)
+				if err != nil {
+					return "", fmt.Errorf("failed to compare previous versions of %s in %s: %w", name, usn.ID, err)
+				}
+
+				if result < 0 {
+					packageStatus = StatusFixedInThisRelease
+				}
+			}
+
+			if statusPrecedence[packageStatus] > statusPrecedence[status] {
+				status = packageStatus
+			}
+		}
+	}
+
+	return status, nil
+}
+
+// filterUSNsByVersions keeps the USNs that the installed versions fixed in
+// this release, returning the status of every USN by its ID. The
+// sources are the source packages of binary packages, so that the versions of
+// the binary packages are compared with USNs that fix their source packages.
+func filterUSNsByVersions(usns []USN, distro string, installed, previous, sources map[string]string, out io.Writer) ([]USN, map[string]string, error) {
+	fmt.Fprintln(out, "Classifying USNs by installed package versions...")
+
+	binaries := map[string][]string{}
+	for name := range installed {
+		if source, ok := sources[name]; ok && source != name {
+			binaries[source] = append(binaries[source], name)
+		}
+	}
+	for _, names := range binaries {
+		slices.Sort(names)
+	}
+
+	var filtered []USN
+	statuses := map[string]string{}
+	for _, usn := range usns {
+		status, err := classifyUSN(usn, distro, installed, previous, binaries)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if status == "" {
+			fmt.Fprintf(out, "USN '%s' does not affect any installed package\n", usn.ID)
+			continue
+		}
+
+		fmt.Fprintf(out, "USN '%s' is %s\n", usn.ID, status)
+		statuses[usn.ID] = status
+		if status == StatusFixedInThisRelease {
+			filtered = append(filtered, usn)
+		}
+	}
+
+	return filtered, statuses, nil
+}
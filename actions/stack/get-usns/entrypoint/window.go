@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// dateWindow is the range of publish dates of the USNs to output. A USN is
+// in the window when it was published at or after since and before until.
+// Either end may be zero, leaving that end open.
+type dateWindow struct {
+	since time.Time
+	until time.Time
+}
+
+// parseTimestamp parses the value of a flag that takes either a date, which
+// is taken as midnight UTC, or an RFC 3339 timestamp.
+func parseTimestamp(name, value string) (time.Time, error) {
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, value)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--%s must be a date such as 2025-12-01 or an RFC 3339 timestamp, got %q", name, value)
+	}
+
+	return t, nil
+}
+
+func parseDateWindow(since, until string) (dateWindow, error) {
+	var window dateWindow
+	var err error
+	if since != "" {
+		window.since, err = parseTimestamp("since", since)
+		if err != nil {
+			return dateWindow{}, err
+		}
+	}
+
+	if until != "" {
+		window.until, err = parseTimestamp("until", until)
+		if err != nil {
+			return dateWindow{}, err
+		}
+	}
+
+	if !window.since.IsZero() && !window.until.IsZero() && !window.since.Before(window.until) {
+		return dateWindow{}, errors.New("--since must be before --until")
+	}
+
+	return window, nil
+}
+
+func (w dateWindow) isOpen() bool {
+	return w.since.IsZero() && w.until.IsZero()
+}
+
+// filterUSNsByPublishDate keeps the USNs published within the window.
+func filterUSNsByPublishDate(usns []USN, window dateWindow, out io.Writer) ([]USN, error) {
+	fmt.Fprintln(out, "Filtering USNs by publish date...")
+
+	var filtered []USN
+	for _, usn := range usns {
+		published, err := time.Parse(publishedLayout, usn.Published)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse publish date of %s: %w", usn.ID, err)
+		}
+
+		if published.Before(window.since) || (!window.until.IsZero() && !published.Before(window.until)) {
+			fmt.Fprintf(out, "USN '%s' was published on %s, outside of the date window, skipping it\n", usn.ID, published.Format(time.DateOnly))
+			continue
+		}
+
+		filtered = append(filtered, usn)
+	}
+
+	return filtered, nil
+}
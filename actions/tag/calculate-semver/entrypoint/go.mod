@@ -7,13 +7,15 @@ toolchain go1.26.3
 require (
 	github.com/Masterminds/semver/v3 v3.4.0
 	github.com/onsi/gomega v1.39.1
+	github.com/paketo-buildpacks/github-config/internal/github v0.0.0
 	github.com/sclevine/spec v1.4.0
-	golang.org/x/oauth2 v0.35.0
+	go.yaml.in/yaml/v3 v3.0.4
 )
 
 require (
 	github.com/google/go-cmp v0.7.0 // indirect
-	go.yaml.in/yaml/v3 v3.0.4 // indirect
 	golang.org/x/net v0.49.0 // indirect
 	golang.org/x/text v0.33.0 // indirect
 )
+
+replace github.com/paketo-buildpacks/github-config/internal/github => ../../../../internal/github
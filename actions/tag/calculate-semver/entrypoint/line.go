@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const releaseLinePattern = `v?(\d+)(?:\.(\d+))?(?:\.x)?`
+
+var (
+	// releaseLine matches release lines such as 1, 1.x, v1.4 or 1.4.x
+	releaseLine = regexp.MustCompile(`^` + releaseLinePattern + `$`)
+
+	// releaseLineBranch matches the last segment of maintenance branch names
+	// such as v1, 1.x, v1.4.x or release-1.4
+	releaseLineBranch = regexp.MustCompile(`^(?:release-|maintenance-)?` + releaseLinePattern + `$`)
+)
+
+// ReleaseLine is the major or major.minor version line that a maintenance
+// branch releases on.
+type ReleaseLine struct {
+	Major    uint64
+	Minor    uint64
+	HasMinor bool
+}
+
+// parseReleaseLine parses lines such as 1, 1.x, v1.4 or 1.4.x.
+func parseReleaseLine(s string) (*ReleaseLine, error) {
+	match := releaseLine.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return nil, fmt.Errorf("invalid release line %q, must be a major or major.minor version such as 1.x or 1.4.x", s)
+	}
+
+	return lineFromMatch(match), nil
+}
+
+// inferReleaseLine returns the release line named by the last segment of a
+// branch, or nil when the branch does not name one.
+func inferReleaseLine(refName string) *ReleaseLine {
+	segment := refName[strings.LastIndex(refName, "/")+1:]
+
+	match := releaseLineBranch.FindStringSubmatch(segment)
+	if match == nil {
+		return nil
+	}
+
+	return lineFromMatch(match)
+}
+
+func lineFromMatch(match []string) *ReleaseLine {
+	// the pattern only matches digits, which only fail to parse when they
+	// overflow
+	major, _ := strconv.ParseUint(match[1], 10, 64)
+	line := &ReleaseLine{Major: major}
+
+	if match[2] != "" {
+		line.Minor, _ = strconv.ParseUint(match[2], 10, 64)
+		line.HasMinor = true
+	}
+
+	return line
+}
+
+// getReleaseLine returns the release line of the configured ref, taken from
+// the --release-lines mapping or else inferred from the branch name. It
+// returns nil when the ref releases across every line.
+func getReleaseLine(config Config) (*ReleaseLine, error) {
+	for _, entry := range strings.FieldsFunc(config.ReleaseLines, func(r rune) bool { return r == ',' || r == '\n' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		branch, line, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(branch) == "" {
+			return nil, fmt.Errorf("--release-lines entry %q must be of the form branch=line", entry)
+		}
+
+		if strings.TrimSpace(branch) == config.RefName {
+			return parseReleaseLine(line)
+		}
+	}
+
+	return inferReleaseLine(config.RefName), nil
+}
+
+// Contains reports whether the version is on the line.
+func (l ReleaseLine) Contains(version *semver.Version) bool {
+	if version.Major() != l.Major {
+		return false
+	}
+
+	return !l.HasMinor || version.Minor() == l.Minor
+}
+
+// First returns the first version released on the line.
+func (l ReleaseLine) First() semver.Version {
+	if l.Major == 0 && l.Minor == 0 {
+		return *semver.MustParse("0.0.1")
+	}
+
+	return *semver.New(l.Major, l.Minor, 0, "", "")
+}
+
+func (l ReleaseLine) String() string {
+	if l.HasMinor {
+		return fmt.Sprintf("%d.%d.x", l.Major, l.Minor)
+	}
+
+	return fmt.Sprintf("%d.x", l.Major)
+}
@@ -1,39 +1,64 @@
 package main
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net/http"
-	"net/http/httputil"
+	"net/url"
 	"os"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
-	"golang.org/x/oauth2"
+	"github.com/paketo-buildpacks/github-config/internal/github"
 )
 
 type Commit struct {
-	SHA string `json:"sha"`
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+	} `json:"commit"`
 }
 
 type Config struct {
 	Endpoint      string
 	Repo          string
-	Token         string
 	RefName       string
 	LatestVersion string
+	Mode          string
+	PRLookup      string
+	Prerelease    string
+	Promote       bool
+	BuildMetadata string
+	PolicyFile    string
+	ReleaseLines  string
+
+	Policy Policy
 }
 
 type Label struct {
 	Name string `json:"name"`
 }
 
+type User struct {
+	Login string `json:"login"`
+	Type  string `json:"type"`
+}
+
+func (u User) isBot() bool {
+	return u.Type == "Bot" || strings.HasSuffix(u.Login, "[bot]")
+}
+
 type PullRequest struct {
 	MergedAt string  `json:"merged_at"`
 	Number   int     `json:"number"`
+	User     User    `json:"user"`
 	Labels   []Label `json:"labels"`
 }
 
@@ -43,21 +68,61 @@ const (
 	MAJOR int = 2
 )
 
+const (
+	// ModeLabels sizes changes using the semver label of each merged PR
+	ModeLabels = "labels"
+
+	// ModeConventionalCommits sizes changes using the type of each commit
+	// message
+	ModeConventionalCommits = "conventional-commits"
+
+	// ModeHybrid sizes changes using the semver label of the PR that merged
+	// each commit, falling back to the type of the commit message
+	ModeHybrid = "hybrid"
+)
+
+const (
+	// PRLookupGraphQL looks up the PRs of many commits in each GraphQL query
+	PRLookupGraphQL = "graphql"
+
+	// PRLookupREST looks up the PRs of each commit with its own REST request
+	PRLookupREST = "rest"
+
+	// graphQLBatchSize is the number of commits whose PRs are looked up in a
+	// single GraphQL query, which keeps each query well within GitHub's node
+	// limits
+	graphQLBatchSize = 50
+
+	// comparePageSize is the largest page of commits the compare API returns
+	comparePageSize = 100
+)
+
+// conventionalCommitHeader matches the "type(scope)!: description" header of
+// a Conventional Commits message.
+var conventionalCommitHeader = regexp.MustCompile(`^([A-Za-z]+)(\([^)]*\))?(!)?: \S`)
+
 func main() {
 	var config Config
 
-	flag.StringVar(&config.Endpoint, "endpoint", "https://api.github.com", "Specifies endpoint for sending requests")
+	flag.StringVar(&config.Endpoint, "endpoint", github.DefaultEndpoint, "Specifies endpoint for sending requests")
 	flag.StringVar(&config.Repo, "repo", "", "Specifies repo for sending requests")
-	flag.StringVar(&config.Token, "token", "", "Github Authorization Token")
 	flag.StringVar(&config.RefName, "ref-name", "", "Ref name of the branch this action is running on")
 	flag.StringVar(&config.LatestVersion, "latest-version", "", "Optional latest version of to base semver calculations off")
+	flag.StringVar(&config.Mode, "mode", ModeLabels, "How to size changes: labels, conventional-commits or hybrid")
+	flag.StringVar(&config.PRLookup, "pull-request-lookup", PRLookupGraphQL, "How to look up the PRs of each commit: graphql or rest")
+	flag.StringVar(&config.Prerelease, "prerelease", "", "Optional prerelease identifier, such as rc, to calculate the next prerelease version")
+	flag.BoolVar(&config.Promote, "promote", false, "Promote the latest prerelease to its final version")
+	flag.StringVar(&config.BuildMetadata, "build-metadata", "", "Optional build metadata appended to the version")
+	flag.StringVar(&config.PolicyFile, "policy-file", "", "Optional path to a policy file in the repo that maps PR labels to sizes")
+	flag.StringVar(&config.ReleaseLines, "release-lines", "", "Optional comma separated branch=line mappings, such as v1-maintenance=1.x, of the release line of each branch")
+	clientFlags := github.RegisterClientFlags(flag.CommandLine, github.ActionClientEnv, 10*time.Minute)
 	flag.Parse()
 
 	if config.Repo == "" {
 		fail(errors.New(`missing required input "repo"`))
 	}
 
-	if config.Token == "" {
+	if !clientFlags.HasCredentials() {
 		fail(errors.New(`missing required input "token"`))
 	}
 
@@ -65,37 +130,109 @@ func main() {
 		fail(errors.New(`missing required input "ref-name"`))
 	}
 
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: config.Token},
-	)
-	ghClient := oauth2.NewClient(ctx, ts)
+	switch config.Mode {
+	case ModeLabels, ModeConventionalCommits, ModeHybrid:
+	default:
+		fail(fmt.Errorf("unsupported mode %q, must be one of %s, %s or %s", config.Mode, ModeLabels, ModeConventionalCommits, ModeHybrid))
+	}
+
+	if config.PRLookup != PRLookupGraphQL && config.PRLookup != PRLookupREST {
+		fail(fmt.Errorf("unsupported pull request lookup %q, must be one of %s or %s", config.PRLookup, PRLookupGraphQL, PRLookupREST))
+	}
+
+	ghClient, err := clientFlags.NewClient(config.Endpoint)
+	if err != nil {
+		fail(err)
+	}
 
 	// Validate that the repo exists
-	uri := fmt.Sprintf("%s/repos/%s", config.Endpoint, config.Repo)
-	resp, err := ghClient.Get(uri)
+	req, err := ghClient.NewRequest("GET", fmt.Sprintf("/repos/%s", config.Repo), nil)
 	if err != nil {
 		fail(err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		dump, _ := httputil.DumpResponse(resp, true)
-		fail(fmt.Errorf("failed to get repo: unexpected response: %s", dump))
+	_, err = ghClient.Do(req, nil)
+	if err != nil {
+		var apiErr *github.Error
+		if errors.As(err, &apiErr) {
+			fail(fmt.Errorf("failed to get repo: unexpected response: %w", err))
+		}
+		fail(err)
+	}
+
+	config.Policy = DefaultPolicy()
+	if config.PolicyFile != "" {
+		fmt.Printf("Reading the label policy from %s\n", config.PolicyFile)
+		config.Policy, err = getPolicy(ghClient, config)
+		if err != nil {
+			fail(err)
+		}
+	}
+
+	line, err := getReleaseLine(config)
+	if err != nil {
+		fail(err)
+	}
+
+	if line != nil {
+		fmt.Printf("Releasing %s on the %s release line\n", config.RefName, line)
 	}
 
 	fmt.Println("Getting the latest release version on the repository")
-	latestReleaseVersion, err := getLatestRelease(ghClient, config)
+	latestReleaseVersion, prereleases, err := getLatestRelease(ghClient, config, line)
 	if err != nil {
 		fail(err)
 	}
 
+	report := Report{
+		Repo: config.Repo,
+		Mode: config.Mode,
+	}
+
+	if line != nil {
+		report.ReleaseLine = line.String()
+	}
+
+	if config.Promote {
+		prerelease, err := latestPrerelease(latestReleaseVersion, prereleases, config.Prerelease)
+		if err != nil {
+			fail(err)
+		}
+
+		fmt.Printf("Promoting %s\n", prerelease.Original())
+		promoted, err := prerelease.SetPrerelease("")
+		if err != nil {
+			fail(err)
+		}
+
+		report.PreviousVersion = prerelease.String()
+		report.Reason = fmt.Sprintf("promoted prerelease %s", prerelease.String())
+		writeVersionOutput(promoted, config, nil, report)
+		return
+	}
+
 	var prevVersion *semver.Version
 	if config.LatestVersion == "" {
 		prevVersion = latestReleaseVersion
 		// there are no releases on the repo
 		if prevVersion == nil {
-			writeTagOutput("0.0.1")
+			// continue the version line of any prereleases that have been
+			// published ahead of the first final release
+			if len(prereleases) > 0 {
+				first, _ := prereleases[len(prereleases)-1].SetPrerelease("")
+				report.Reason = "there are no published final releases, continuing the version line of the published prereleases"
+				writeVersionOutput(first, config, prereleases, report)
+				os.Exit(0)
+			}
+
+			if line != nil {
+				report.Reason = fmt.Sprintf("there are no published releases on the %s release line", line)
+				writeVersionOutput(line.First(), config, prereleases, report)
+				os.Exit(0)
+			}
+
+			report.Reason = "there are no published releases"
+			writeVersionOutput(*semver.MustParse("0.0.1"), config, prereleases, report)
 			os.Exit(0)
 
 		}
@@ -111,26 +248,39 @@ func main() {
 		// release to be X.Y.0 (rather than X.Y.1)
 		if latestReleaseVersion == nil || (prevVersion.GreaterThan(latestReleaseVersion) && prevVersion.Patch() == 0) {
 			fmt.Println("First release in the new version line, using `latest-version` as output")
-			writeTagOutput(prevVersion.String())
+			report.Reason = "first release in the new version line given by latest-version"
+			writeVersionOutput(*prevVersion, config, prereleases, report)
 			return
 		}
 	}
 
 	fmt.Printf("Basing next semantic version off of %s\n", prevVersion.String())
-	PRsWithSizes, err := getPRsSinceLastRelease(ghClient, config, prevVersion)
+	changes, err := getChangesSinceLastRelease(ghClient, config, prevVersion)
 	if err != nil {
 		fail(err)
 	}
 
 	largestChange := PATCH
-	for _, v := range PRsWithSizes {
-		if v > largestChange {
-			largestChange = v
-		}
+	report.Reason = fmt.Sprintf("no change since %s called for more than a patch", prevVersion.String())
+	if driver := drivingChange(changes); driver != nil {
+		largestChange = driver.size
+		report.DrivenBy = driver
+		report.Reason = fmt.Sprintf("the largest change since %s was a %s", prevVersion.String(), driver.Size)
 	}
 
+	report.PreviousVersion = prevVersion.String()
+	report.Bump = sizeToName(largestChange)
+	report.Changes = changes
+
 	next := calculateNextSemver(*prevVersion, largestChange)
-	writeTagOutput(next.String())
+
+	// Bumps that leave the line would collide with the releases of a newer
+	// line, which are made from another branch
+	if line != nil && !line.Contains(&next) {
+		fail(fmt.Errorf("the %s bump from %s to %s would leave the %s release line of %s", report.Bump, prevVersion.String(), next.String(), line, config.RefName))
+	}
+
+	writeVersionOutput(next, config, prereleases, report)
 }
 
 func fail(err error) {
@@ -138,37 +288,36 @@ func fail(err error) {
 	os.Exit(1)
 }
 
-func getLatestRelease(client *http.Client, config Config) (*semver.Version, error) {
-	uri := fmt.Sprintf("%s/repos/%s/releases", config.Endpoint, config.Repo)
-	resp, err := client.Get(uri)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// The repo has no releases
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, nil
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		dump, _ := httputil.DumpResponse(resp, true)
-		return nil, fmt.Errorf("failed to get latest release: unexpected response: %s", dump)
-	}
-
+// getLatestRelease returns the highest published final release, along with
+// every published prerelease sorted in ascending order. When there is a
+// release line, releases on other lines are left out.
+func getLatestRelease(client *github.Client, config Config, line *ReleaseLine) (*semver.Version, []*semver.Version, error) {
 	type release struct {
 		TagName string `json:"tag_name"`
 		Draft   bool   `json:"draft"`
 	}
 
-	var releases []release
-
-	err = json.NewDecoder(resp.Body).Decode(&releases)
+	releases, err := github.GetAll[release](client, fmt.Sprintf("/repos/%s/releases", config.Repo))
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode releases: %w", err)
+		var apiErr *github.Error
+		if errors.As(err, &apiErr) {
+			// The repo has no releases
+			if apiErr.StatusCode == http.StatusNotFound {
+				return nil, nil, nil
+			}
+
+			return nil, nil, fmt.Errorf("failed to get latest release: unexpected response: %w", err)
+		}
+
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return nil, nil, err
+		}
+
+		return nil, nil, fmt.Errorf("failed to decode releases: %w", err)
 	}
 
-	var tags []*semver.Version
+	var tags, prereleases []*semver.Version
 	// include all semantically versioned non-draft release tags
 	for _, r := range releases {
 		if !r.Draft {
@@ -176,98 +325,390 @@ func getLatestRelease(client *http.Client, config Config) (*semver.Version, erro
 			if err != nil {
 				continue
 			}
+
+			if line != nil && !line.Contains(semverTag) {
+				continue
+			}
+
+			if semverTag.Prerelease() != "" {
+				prereleases = append(prereleases, semverTag)
+				continue
+			}
 			tags = append(tags, semverTag)
 		}
 	}
 
+	sort.Sort(semver.Collection(prereleases))
+
 	if len(tags) == 0 {
 		fmt.Println("No semantically versioned published releases found")
-		return nil, nil
+		return nil, prereleases, nil
 	}
 
 	sort.Sort(semver.Collection(tags))
 	// return highest versioned tag
-	return tags[len(tags)-1], nil
+	return tags[len(tags)-1], prereleases, nil
 }
 
-func getPRsSinceLastRelease(client *http.Client, config Config, previous *semver.Version) (map[int]int, error) {
-	PRsWithSizes := map[int]int{}
-	uri := fmt.Sprintf("%s/repos/%s/compare/%s...%s", config.Endpoint, config.Repo, previous.Original(), config.RefName)
-	resp, err := client.Get(uri)
+// nextPrerelease returns the next "<identifier>.N" prerelease of the version,
+// counting up from the prereleases already published for it.
+func nextPrerelease(version semver.Version, identifier string, prereleases []*semver.Version) (semver.Version, error) {
+	number := 0
+	for _, prerelease := range prereleases {
+		if prerelease.Major() != version.Major() || prerelease.Minor() != version.Minor() || prerelease.Patch() != version.Patch() {
+			continue
+		}
+
+		suffix, ok := strings.CutPrefix(prerelease.Prerelease(), identifier+".")
+		if !ok {
+			continue
+		}
+
+		n, err := strconv.Atoi(suffix)
+		if err == nil && n > number {
+			number = n
+		}
+	}
+
+	next, err := version.SetPrerelease(fmt.Sprintf("%s.%d", identifier, number+1))
 	if err != nil {
-		return nil, err
+		return semver.Version{}, fmt.Errorf("--prerelease is not a valid prerelease identifier: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		dump, _ := httputil.DumpResponse(resp, true)
-		return nil, fmt.Errorf("failed to get commits since last release: unexpected response: %s", dump)
+	return next, nil
+}
+
+// latestPrerelease returns the highest prerelease published since the latest
+// final release. When an identifier is given only prereleases with that
+// identifier are considered.
+func latestPrerelease(latest *semver.Version, prereleases []*semver.Version, identifier string) (*semver.Version, error) {
+	for i := len(prereleases) - 1; i >= 0; i-- {
+		prerelease := prereleases[i]
+		if latest != nil && !prerelease.GreaterThan(latest) {
+			break
+		}
+
+		if identifier != "" && !strings.HasPrefix(prerelease.Prerelease(), identifier+".") && prerelease.Prerelease() != identifier {
+			continue
+		}
+
+		return prerelease, nil
 	}
 
-	var comparison struct {
-		Commits []Commit `json:"commits"`
+	return nil, errors.New("no prerelease found to promote")
+}
+
+// getCommitsSinceLastRelease pages through the comparison, as an unpaginated
+// compare response is truncated at 250 commits.
+func getCommitsSinceLastRelease(client *github.Client, config Config, previous *semver.Version) ([]Commit, error) {
+	var commits []Commit
+
+	path := fmt.Sprintf("/repos/%s/compare/%s...%s?per_page=%d", config.Repo, previous.Original(), config.RefName, comparePageSize)
+	for path != "" {
+		req, err := client.NewRequest("GET", path, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.BareDo(req)
+		if err != nil {
+			var apiErr *github.Error
+			if errors.As(err, &apiErr) {
+				return nil, fmt.Errorf("failed to get commits since last release: unexpected response: %w", err)
+			}
+			return nil, err
+		}
+
+		var comparison struct {
+			Commits []Commit `json:"commits"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&comparison)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse commits since release response: %w", err)
+		}
+
+		commits = append(commits, comparison.Commits...)
+		path = resp.NextURL
 	}
-	err = json.NewDecoder(resp.Body).Decode(&comparison)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse commits since release response: %w", err)
+
+	return commits, nil
+}
+
+// getCommitsPRs returns the PRs associated with each commit, keyed by SHA.
+func getCommitsPRs(client *github.Client, config Config, commits []Commit) (map[string][]PullRequest, error) {
+	commitsPRs := map[string][]PullRequest{}
+
+	if config.PRLookup == PRLookupREST {
+		for _, commit := range commits {
+			commitPRs, err := getCommitPRs(client, config, commit.SHA)
+			if err != nil {
+				return nil, err
+			}
+			commitsPRs[commit.SHA] = commitPRs
+		}
+
+		return commitsPRs, nil
 	}
 
-	for _, commit := range comparison.Commits {
-		uri = fmt.Sprintf("%s/repos/%s/commits/%s/pulls", config.Endpoint, config.Repo, commit.SHA)
-		resp, err = client.Get(uri)
+	for start := 0; start < len(commits); start += graphQLBatchSize {
+		batch := commits[start:min(start+graphQLBatchSize, len(commits))]
+
+		batchPRs, err := queryCommitsPRs(client, config, batch)
 		if err != nil {
 			return nil, err
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			dump, _ := httputil.DumpResponse(resp, true)
-			return nil, fmt.Errorf("failed to get pull requests for commit: unexpected response: %s", dump)
+		for sha, commitPRs := range batchPRs {
+			commitsPRs[sha] = commitPRs
+		}
+	}
+
+	return commitsPRs, nil
+}
+
+// queryCommitsPRs looks up the PRs associated with a batch of commits, and
+// their labels, in a single GraphQL query.
+func queryCommitsPRs(client *github.Client, config Config, commits []Commit) (map[string][]PullRequest, error) {
+	owner, name, _ := strings.Cut(config.Repo, "/")
+	variables := map[string]interface{}{
+		"owner": owner,
+		"name":  name,
+	}
+
+	var parameters, lookups []string
+	for i, commit := range commits {
+		alias := fmt.Sprintf("c%d", i)
+		variables[alias] = commit.SHA
+		parameters = append(parameters, fmt.Sprintf("$%s: GitObjectID!", alias))
+		lookups = append(lookups, fmt.Sprintf("%s: object(oid: $%s) { ...commitPRs }", alias, alias))
+	}
+
+	query := fmt.Sprintf(`query($owner: String!, $name: String!, %s) {
+  repository(owner: $owner, name: $name) {
+    %s
+  }
+}
+
+fragment commitPRs on Commit {
+  associatedPullRequests(first: 10) {
+    nodes {
+      number
+      mergedAt
+      author {
+        login
+        __typename
+      }
+      labels(first: 100) {
+        nodes {
+          name
+        }
+      }
+    }
+  }
+}`, strings.Join(parameters, ", "), strings.Join(lookups, "\n    "))
+
+	var data struct {
+		Repository map[string]*struct {
+			AssociatedPullRequests struct {
+				Nodes []struct {
+					Number   int    `json:"number"`
+					MergedAt string `json:"mergedAt"`
+					Author   struct {
+						Login    string `json:"login"`
+						Typename string `json:"__typename"`
+					} `json:"author"`
+					Labels struct {
+						Nodes []Label `json:"nodes"`
+					} `json:"labels"`
+				} `json:"nodes"`
+			} `json:"associatedPullRequests"`
+		} `json:"repository"`
+	}
+	err := client.GraphQL(query, variables, &data)
+	if err != nil {
+		var apiErr *github.Error
+		if errors.As(err, &apiErr) {
+			return nil, fmt.Errorf("failed to get pull requests for commits: unexpected response: %w", err)
+		}
+
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("failed to parse commit PRs response: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get pull requests for commits: %w", err)
+	}
+
+	commitsPRs := map[string][]PullRequest{}
+	for i, commit := range commits {
+		object := data.Repository[fmt.Sprintf("c%d", i)]
+		if object == nil {
+			return nil, fmt.Errorf("failed to get pull requests for commits: commit %s not found", commit.SHA)
 		}
 
 		var commitPRs []PullRequest
-		err = json.NewDecoder(resp.Body).Decode(&commitPRs)
+		for _, node := range object.AssociatedPullRequests.Nodes {
+			commitPRs = append(commitPRs, PullRequest{
+				Number:   node.Number,
+				MergedAt: node.MergedAt,
+				User:     User{Login: node.Author.Login, Type: node.Author.Typename},
+				Labels:   node.Labels.Nodes,
+			})
+		}
+		commitsPRs[commit.SHA] = commitPRs
+	}
+
+	return commitsPRs, nil
+}
+
+func getCommitPRs(client *github.Client, config Config, sha string) ([]PullRequest, error) {
+	req, err := client.NewRequest("GET", fmt.Sprintf("/repos/%s/commits/%s/pulls", config.Repo, sha), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.BareDo(req)
+	if err != nil {
+		var apiErr *github.Error
+		if errors.As(err, &apiErr) {
+			return nil, fmt.Errorf("failed to get pull requests for commit: unexpected response: %w", err)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var commitPRs []PullRequest
+	err = json.NewDecoder(resp.Body).Decode(&commitPRs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse commit PRs response: %w", err)
+	}
+
+	return commitPRs, nil
+}
+
+// getChangesSinceLastRelease returns every change made since the previous
+// release, sized as determined by the configured mode.
+func getChangesSinceLastRelease(client *github.Client, config Config, previous *semver.Version) ([]Change, error) {
+	commits, err := getCommitsSinceLastRelease(client, config, previous)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	if config.Mode == ModeConventionalCommits {
+		for _, commit := range commits {
+			changes = append(changes, newChange(commit, nil, commitToSize(commit), SourceCommit))
+		}
+
+		return changes, nil
+	}
+
+	commitsPRs, err := getCommitsPRs(client, config, commits)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, commit := range commits {
+		commitPRs := commitsPRs[commit.SHA]
+
+		PRsWithSizes, err := sizePRsByLabel(commitPRs, config.Policy)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse commit PRs response: %w", err)
+			return nil, err
 		}
 
-		for _, pr := range commitPRs {
+		merged := false
+		for i, pr := range commitPRs {
 			if pr.MergedAt == "" {
 				continue
 			}
-			for _, label := range pr.Labels {
-				newSize, err := labelToSize(label.Name)
-				if err != nil {
-					continue
-				}
-				if prevSize, ok := PRsWithSizes[pr.Number]; ok && prevSize != newSize {
-					return nil, fmt.Errorf("PR %d has multiple semver labels", pr.Number)
-				}
-				PRsWithSizes[pr.Number] = newSize
-			}
-			if _, ok := PRsWithSizes[pr.Number]; !ok {
+			merged = true
+
+			// Labels take precedence over the type of the commit
+			label, labelled := PRsWithSizes[pr.Number]
+			botSize, isBot := config.Policy.botSize(pr)
+			switch {
+			case config.Policy.isExempt(pr):
+				changes = append(changes, newChange(commit, &commitPRs[i], -1, SourceNone))
+			case labelled:
+				change := newChange(commit, &commitPRs[i], label.size, SourceLabel)
+				change.label = label.name
+				changes = append(changes, change)
+			case isBot:
+				changes = append(changes, newChange(commit, &commitPRs[i], botSize, SourceDefault))
+			case config.Mode == ModeHybrid:
+				changes = append(changes, newChange(commit, &commitPRs[i], commitToSize(commit), SourceCommit))
+			default:
 				return nil, fmt.Errorf("PR %d has no semver label", pr.Number)
 			}
 		}
+
+		if !merged {
+			if config.Mode == ModeHybrid {
+				changes = append(changes, newChange(commit, nil, commitToSize(commit), SourceCommit))
+			} else {
+				changes = append(changes, newChange(commit, nil, -1, SourceNone))
+			}
+		}
 	}
-	return PRsWithSizes, nil
+
+	return changes, nil
 }
 
-func isSemverLabel(label string) bool {
-	return label == "semver:patch" || label == "semver:minor" || label == "semver:major"
+// sizedLabel is the label that gave a PR its size.
+type sizedLabel struct {
+	name string
+	size int
 }
 
-func labelToSize(label string) (int, error) {
-	switch label {
-	case "semver:patch":
-		return PATCH, nil
-	case "semver:minor":
-		return MINOR, nil
-	case "semver:major":
-		return MAJOR, nil
-	default:
-		return -1, fmt.Errorf("not a semver label")
+// sizePRsByLabel returns the size given by the semver label of each merged
+// PR, as mapped by the policy. PRs without a semver label are left out.
+func sizePRsByLabel(PRs []PullRequest, policy Policy) (map[int]sizedLabel, error) {
+	PRsWithSizes := map[int]sizedLabel{}
+	for _, pr := range PRs {
+		if pr.MergedAt == "" {
+			continue
+		}
+		for _, label := range pr.Labels {
+			newSize, ok := policy.labelSize(label.Name)
+			if !ok {
+				continue
+			}
+			if prev, ok := PRsWithSizes[pr.Number]; ok && prev.size != newSize {
+				return nil, fmt.Errorf("PR %d has multiple semver labels", pr.Number)
+			}
+			PRsWithSizes[pr.Number] = sizedLabel{name: label.Name, size: newSize}
+		}
+	}
+	return PRsWithSizes, nil
+}
+
+// commitToSize returns the size of a change from its Conventional Commits
+// message, see https://www.conventionalcommits.org/en/v1.0.0/. Commits that
+// do not follow the convention are treated as patches.
+func commitToSize(commit Commit) int {
+	header, body, _ := strings.Cut(commit.Commit.Message, "\n")
+
+	match := conventionalCommitHeader.FindStringSubmatch(strings.TrimSpace(header))
+	if match == nil {
+		fmt.Printf("Commit %s does not follow Conventional Commits, treating it as a patch\n", commit.SHA)
+		return PATCH
+	}
+
+	if match[3] == "!" {
+		return MAJOR
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			return MAJOR
+		}
+	}
+
+	if strings.ToLower(match[1]) == "feat" {
+		return MINOR
 	}
+
+	return PATCH
 }
 
 func calculateNextSemver(previous semver.Version, largestChange int) semver.Version {
@@ -284,6 +725,31 @@ func calculateNextSemver(previous semver.Version, largestChange int) semver.Vers
 	return semver.Version{}
 }
 
+// writeVersionOutput writes the version as the tag output, as the next
+// prerelease for the configured identifier and with any build metadata
+// appended, along with the report explaining it.
+func writeVersionOutput(version semver.Version, config Config, prereleases []*semver.Version, report Report) {
+	var err error
+	if config.Prerelease != "" && !config.Promote {
+		version, err = nextPrerelease(version, config.Prerelease, prereleases)
+		if err != nil {
+			fail(err)
+		}
+	}
+
+	if config.BuildMetadata != "" {
+		version, err = version.SetMetadata(config.BuildMetadata)
+		if err != nil {
+			fail(fmt.Errorf("--build-metadata is not valid build metadata: %w", err))
+		}
+	}
+
+	writeTagOutput(version.String())
+
+	report.Version = version.String()
+	writeReportOutput(report)
+}
+
 func writeTagOutput(tag string) {
 	outputFileName, ok := os.LookupEnv("GITHUB_OUTPUT")
 	if !ok {
@@ -3,10 +3,12 @@ package main_test
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/http/httputil"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -17,6 +19,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/gexec"
+	"github.com/paketo-buildpacks/github-config/internal/github/githubtest"
 	"github.com/sclevine/spec"
 )
 
@@ -40,12 +43,7 @@ func TestEntrypoint(t *testing.T) {
 		)
 
 		it.Before(func() {
-			api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-				dump, _ := httputil.DumpRequest(req, true)
-				receivedRequest, _ := http.ReadRequest(bufio.NewReader(bytes.NewBuffer(dump)))
-
-				requests = append(requests, receivedRequest)
-
+			rest := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 				switch req.URL.Path {
 				case
 					"/repos/some-org/some-broken-commits-repo",
@@ -269,8 +267,22 @@ func TestEntrypoint(t *testing.T) {
 					fmt.Fprintln(w, `{ "message": "Malformed JSON }`)
 
 				default:
-					t.Fatalf("unknown request: %s", dump)
+					t.Fatalf("unknown request: %s %s", req.Method, req.URL)
 				}
+			})
+
+			api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				dump, _ := httputil.DumpRequest(req, true)
+				receivedRequest, _ := http.ReadRequest(bufio.NewReader(bytes.NewBuffer(dump)))
+
+				requests = append(requests, receivedRequest)
+
+				if req.Method == http.MethodPost && req.URL.Path == "/graphql" {
+					serveCommitsPRs(w, req, rest)
+					return
+				}
+
+				rest.ServeHTTP(w, req)
 			}))
 
 			tempDir = t.TempDir()
@@ -304,14 +316,12 @@ func TestEntrypoint(t *testing.T) {
 
 				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
 
-				Expect(requests).To(HaveLen(6))
+				Expect(requests).To(HaveLen(4))
 
 				Expect(requests[0].URL.Path).To(Equal("/repos/some-org/some-patch-repo"))
 				Expect(requests[1].URL.Path).To(Equal("/repos/some-org/some-patch-repo/releases"))
 				Expect(requests[2].URL.Path).To(Equal("/repos/some-org/some-patch-repo/compare/v1.2.3...some-ref-name"))
-				Expect(requests[3].URL.Path).To(Equal("/repos/some-org/some-patch-repo/commits/abcdef/pulls"))
-				Expect(requests[4].URL.Path).To(Equal("/repos/some-org/some-patch-repo/commits/ghijklm/pulls"))
-				Expect(requests[5].URL.Path).To(Equal("/repos/some-org/some-patch-repo/commits/openstill/pulls"))
+				Expect(requests[3].URL.Path).To(Equal("/graphql"))
 
 				outputContains(`tag=1.2.4`)
 			})
@@ -338,14 +348,12 @@ func TestEntrypoint(t *testing.T) {
 
 				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
 
-				Expect(requests).To(HaveLen(6))
+				Expect(requests).To(HaveLen(4))
 
 				Expect(requests[0].URL.Path).To(Equal("/repos/some-org/some-minor-repo"))
 				Expect(requests[1].URL.Path).To(Equal("/repos/some-org/some-minor-repo/releases"))
 				Expect(requests[2].URL.Path).To(Equal("/repos/some-org/some-minor-repo/compare/v1.2.3...some-ref-name"))
-				Expect(requests[3].URL.Path).To(Equal("/repos/some-org/some-minor-repo/commits/abcdef/pulls"))
-				Expect(requests[4].URL.Path).To(Equal("/repos/some-org/some-minor-repo/commits/ghijklm/pulls"))
-				Expect(requests[5].URL.Path).To(Equal("/repos/some-org/some-minor-repo/commits/openstill/pulls"))
+				Expect(requests[3].URL.Path).To(Equal("/graphql"))
 
 				outputContains(`tag=1.3.0`)
 			})
@@ -372,14 +380,12 @@ func TestEntrypoint(t *testing.T) {
 
 				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
 
-				Expect(requests).To(HaveLen(6))
+				Expect(requests).To(HaveLen(4))
 
 				Expect(requests[0].URL.Path).To(Equal("/repos/some-org/some-major-repo"))
 				Expect(requests[1].URL.Path).To(Equal("/repos/some-org/some-major-repo/releases"))
 				Expect(requests[2].URL.Path).To(Equal("/repos/some-org/some-major-repo/compare/v1.2.3...some-ref-name"))
-				Expect(requests[3].URL.Path).To(Equal("/repos/some-org/some-major-repo/commits/abcdef/pulls"))
-				Expect(requests[4].URL.Path).To(Equal("/repos/some-org/some-major-repo/commits/ghijklm/pulls"))
-				Expect(requests[5].URL.Path).To(Equal("/repos/some-org/some-major-repo/commits/openstill/pulls"))
+				Expect(requests[3].URL.Path).To(Equal("/graphql"))
 
 				outputContains(`tag=2.0.0`)
 			})
@@ -498,14 +504,12 @@ func TestEntrypoint(t *testing.T) {
 
 				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
 
-				Expect(requests).To(HaveLen(6))
+				Expect(requests).To(HaveLen(4))
 
 				Expect(requests[0].URL.Path).To(Equal("/repos/some-org/some-patch-repo"))
 				Expect(requests[1].URL.Path).To(Equal("/repos/some-org/some-patch-repo/releases"))
 				Expect(requests[2].URL.Path).To(Equal("/repos/some-org/some-patch-repo/compare/v1.1.2...some-ref-name"))
-				Expect(requests[3].URL.Path).To(Equal("/repos/some-org/some-patch-repo/commits/abcdef/pulls"))
-				Expect(requests[4].URL.Path).To(Equal("/repos/some-org/some-patch-repo/commits/ghijklm/pulls"))
-				Expect(requests[5].URL.Path).To(Equal("/repos/some-org/some-patch-repo/commits/openstill/pulls"))
+				Expect(requests[3].URL.Path).To(Equal("/graphql"))
 
 				outputContains(`tag=1.1.3`)
 			})
@@ -801,7 +805,29 @@ func TestEntrypoint(t *testing.T) {
 
 					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
 
-					Expect(buffer).To(gbytes.Say(`failed to get pull requests for commit: unexpected response`))
+					Expect(buffer).To(gbytes.Say(`failed to get pull requests for commits: unexpected response`))
+				})
+
+				context("when the PRs are looked up over REST", func() {
+					it("prints an error and exits non-zero", func() {
+						command := exec.Command(
+							entrypoint,
+							"--endpoint", api.URL,
+							"--repo", "some-org/some-broken-pulls-repo",
+							"--token", "some-github-token",
+							"--ref-name", "some-ref-name",
+							"--pull-request-lookup", "rest",
+						)
+
+						buffer := gbytes.NewBuffer()
+
+						session, err := gexec.Start(command, buffer, buffer)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+						Expect(buffer).To(gbytes.Say(`failed to get pull requests for commit: unexpected response`))
+					})
 				})
 			})
 
@@ -824,6 +850,28 @@ func TestEntrypoint(t *testing.T) {
 
 					Expect(buffer).To(gbytes.Say(`failed to parse commit PRs response:`))
 				})
+
+				context("when the PRs are looked up over REST", func() {
+					it("prints an error and exits non-zero", func() {
+						command := exec.Command(
+							entrypoint,
+							"--endpoint", api.URL,
+							"--repo", "some-org/some-malformed-pulls-repo",
+							"--token", "some-github-token",
+							"--ref-name", "some-ref-name",
+							"--pull-request-lookup", "rest",
+						)
+
+						buffer := gbytes.NewBuffer()
+
+						session, err := gexec.Start(command, buffer, buffer)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+						Expect(buffer).To(gbytes.Say(`failed to parse commit PRs response:`))
+					})
+				})
 			})
 
 			context("when a PR has no semver label", func() {
@@ -867,6 +915,796 @@ func TestEntrypoint(t *testing.T) {
 					Expect(buffer).To(gbytes.Say(`PR 1 has multiple semver labels`))
 				})
 			})
+
+			context("when the mode is not supported", func() {
+				it("prints an error and exits non-zero", func() {
+					command := exec.Command(
+						entrypoint,
+						"--endpoint", api.URL,
+						"--repo", "some-org/some-patch-repo",
+						"--token", "some-github-token",
+						"--ref-name", "some-ref-name",
+						"--mode", "some-mode",
+					)
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+					Expect(buffer).To(gbytes.Say(`unsupported mode "some-mode", must be one of labels, conventional-commits or hybrid`))
+				})
+			})
+		})
+	})
+
+	spec.Run(t, "calculate-semver against a fake GitHub", func(t *testing.T, context spec.G, it spec.S) {
+		var (
+			Expect     = NewWithT(t).Expect
+			Eventually = NewWithT(t).Eventually
+
+			server  *githubtest.Server
+			repo    *githubtest.Repo
+			tempDir string
+		)
+
+		it.Before(func() {
+			server = githubtest.NewServer()
+			repo = server.AddRepo("some-org/some-repo").
+				WithCommit("sha-1", "Initial commit").
+				WithRelease(githubtest.Release{TagName: "v1.2.3"})
+
+			tempDir = t.TempDir()
+		})
+
+		it.After(func() {
+			server.Close()
+		})
+
+		calculate := func(args ...string) (string, *gbytes.Buffer) {
+			command := exec.Command(entrypoint, append([]string{
+				"--endpoint", server.URL,
+				"--repo", "some-org/some-repo",
+				"--token", "some-github-token",
+				"--ref-name", "main",
+			}, args...)...)
+			command.Env = []string{
+				fmt.Sprintf("GITHUB_OUTPUT=%s", filepath.Join(tempDir, "github-output")),
+			}
+
+			buffer := gbytes.NewBuffer()
+
+			session, err := gexec.Start(command, buffer, buffer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+			data, err := os.ReadFile(filepath.Join(tempDir, "github-output"))
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, line := range strings.Split(string(data), "\n") {
+				if strings.HasPrefix(line, "tag=") {
+					return line, buffer
+				}
+			}
+
+			return "", buffer
+		}
+
+		readReport := func() string {
+			data, err := os.ReadFile(filepath.Join(tempDir, "github-output"))
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, line := range strings.Split(string(data), "\n") {
+				if report, ok := strings.CutPrefix(line, "report="); ok {
+					return report
+				}
+			}
+
+			return ""
+		}
+
+		context("conventional-commits", func() {
+			it("increments the patch for fixes", func() {
+				repo.WithCommit("sha-2", "fix: handle empty payloads").
+					WithCommit("sha-3", "chore(deps): bump dependencies")
+
+				tag, _ := calculate("--mode", "conventional-commits")
+				Expect(tag).To(Equal("tag=1.2.4"))
+			})
+
+			it("increments the minor for features", func() {
+				repo.WithCommit("sha-2", "fix: handle empty payloads").
+					WithCommit("sha-3", "feat(api): support dispatching to many repos")
+
+				tag, _ := calculate("--mode", "conventional-commits")
+				Expect(tag).To(Equal("tag=1.3.0"))
+			})
+
+			it("increments the major for breaking changes marked with !", func() {
+				repo.WithCommit("sha-2", "feat!: drop support for the v1 API")
+
+				tag, _ := calculate("--mode", "conventional-commits")
+				Expect(tag).To(Equal("tag=2.0.0"))
+			})
+
+			it("increments the major for breaking changes in a footer", func() {
+				repo.WithCommit("sha-2", "fix: rename the payload flag\n\nBREAKING CHANGE: --data is now --payload")
+
+				tag, _ := calculate("--mode", "conventional-commits")
+				Expect(tag).To(Equal("tag=2.0.0"))
+			})
+
+			it("treats other commits as patches without looking at PRs", func() {
+				repo.WithMergedPullRequest("sha-2", "Update README")
+
+				tag, buffer := calculate("--mode", "conventional-commits")
+				Expect(tag).To(Equal("tag=1.2.4"))
+				Expect(buffer).To(gbytes.Say(`Commit sha-2 does not follow Conventional Commits, treating it as a patch`))
+
+				for _, request := range server.Requests() {
+					Expect(request.Path).NotTo(HaveSuffix("/pulls"))
+				}
+			})
+		})
+
+		context("hybrid", func() {
+			it("uses PR labels over the commit type", func() {
+				repo.WithMergedPullRequest("sha-2", "fix: rename the payload flag", "semver:major")
+
+				tag, _ := calculate("--mode", "hybrid")
+				Expect(tag).To(Equal("tag=2.0.0"))
+			})
+
+			it("falls back to the commit type for unlabelled PRs", func() {
+				repo.WithMergedPullRequest("sha-2", "fix: handle empty payloads", "semver:patch").
+					WithMergedPullRequest("sha-3", "feat: support dispatching to many repos")
+
+				tag, _ := calculate("--mode", "hybrid")
+				Expect(tag).To(Equal("tag=1.3.0"))
+			})
+
+			it("falls back to the commit type for commits without a PR", func() {
+				repo.WithCommit("sha-2", "feat: support dispatching to many repos")
+
+				tag, _ := calculate("--mode", "hybrid")
+				Expect(tag).To(Equal("tag=1.3.0"))
+			})
+		})
+
+		context("policy file", func() {
+			it.Before(func() {
+				repo.WithFile(".github/semver-policy.yml", []byte(`
+labels:
+  release:major: major
+  release:minor: minor
+  release:patch: patch
+aliases:
+  breaking: release:major
+  dependencies: release:patch
+exempt:
+  - skip-release
+bots:
+  default: patch
+`))
+			})
+
+			it("sizes PRs by the labels of the policy", func() {
+				repo.WithMergedPullRequest("sha-2", "Fixes a bug", "release:patch").
+					WithMergedPullRequest("sha-3", "Adds a feature", "release:minor")
+
+				tag, _ := calculate("--policy-file", ".github/semver-policy.yml")
+				Expect(tag).To(Equal("tag=1.3.0"))
+			})
+
+			it("follows label aliases", func() {
+				repo.WithMergedPullRequest("sha-2", "Drops the v1 API", "breaking")
+
+				tag, buffer := calculate("--policy-file", ".github/semver-policy.yml")
+				Expect(tag).To(Equal("tag=2.0.0"))
+				Expect(buffer).To(gbytes.Say(`Reading the label policy from .github/semver-policy.yml`))
+			})
+
+			it("leaves out exempt PRs", func() {
+				repo.WithMergedPullRequest("sha-2", "Fixes a bug", "release:patch").
+					WithMergedPullRequest("sha-3", "Updates the README", "skip-release")
+
+				tag, _ := calculate("--policy-file", ".github/semver-policy.yml")
+				Expect(tag).To(Equal("tag=1.2.4"))
+				Expect(readReport()).To(ContainSubstring(`"pull_request":2,"labels":["skip-release"],"size":"none","source":"none"`))
+			})
+
+			it("gives unlabeled bot PRs the default size", func() {
+				repo.WithCommit("sha-2", "Bump some-dependency").
+					WithPullRequest(githubtest.PullRequest{Author: "dependabot[bot]", Commits: []string{"sha-2"}, MergedAt: time.Now()})
+
+				tag, _ := calculate("--policy-file", ".github/semver-policy.yml")
+				Expect(tag).To(Equal("tag=1.2.4"))
+				Expect(readReport()).To(ContainSubstring(`"size":"patch","source":"default"`))
+
+				tag, _ = calculate("--policy-file", ".github/semver-policy.yml", "--pull-request-lookup", "rest")
+				Expect(tag).To(Equal("tag=1.2.4"))
+			})
+
+			it("escapes the path of the policy file and the ref", func() {
+				repo.WithFile(".github/semver policy #1.yml", []byte("labels:\n  release:patch: patch\n")).
+					WithMergedPullRequest("sha-2", "Fixes a bug", "release:patch").
+					WithBranch("fix&release+1", "sha-2")
+
+				tag, _ := calculate("--policy-file", ".github/semver policy #1.yml", "--ref-name", "fix&release+1")
+				Expect(tag).To(Equal("tag=1.2.4"))
+
+				var contents []githubtest.Request
+				for _, request := range server.Requests() {
+					if strings.Contains(request.Path, "/contents/") {
+						contents = append(contents, request)
+					}
+				}
+
+				Expect(contents).To(HaveLen(1))
+				Expect(contents[0].Path).To(Equal("/repos/some-org/some-repo/contents/.github/semver policy #1.yml"))
+				Expect(contents[0].Query).To(Equal(url.Values{"ref": {"fix&release+1"}}))
+			})
+
+			it("no longer recognises the default labels", func() {
+				repo.WithMergedPullRequest("sha-2", "Fixes a bug", "semver:patch")
+
+				command := exec.Command(
+					entrypoint,
+					"--endpoint", server.URL,
+					"--repo", "some-org/some-repo",
+					"--token", "some-github-token",
+					"--ref-name", "main",
+					"--policy-file", ".github/semver-policy.yml",
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+				Expect(buffer).To(gbytes.Say(`PR 1 has no semver label`))
+			})
+
+			context("failure cases", func() {
+				context("when the policy file does not exist", func() {
+					it("prints an error and exits non-zero", func() {
+						command := exec.Command(
+							entrypoint,
+							"--endpoint", server.URL,
+							"--repo", "some-org/some-repo",
+							"--token", "some-github-token",
+							"--ref-name", "main",
+							"--policy-file", ".github/missing-policy.yml",
+						)
+
+						buffer := gbytes.NewBuffer()
+
+						session, err := gexec.Start(command, buffer, buffer)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+						Expect(buffer).To(gbytes.Say(`policy file .github/missing-policy.yml not found on main`))
+					})
+				})
+
+				context("when an alias refers to an unknown label", func() {
+					it.Before(func() {
+						repo.WithFile(".github/semver-policy.yml", []byte("aliases:\n  breaking: semver:huge\n"))
+					})
+
+					it("prints an error and exits non-zero", func() {
+						command := exec.Command(
+							entrypoint,
+							"--endpoint", server.URL,
+							"--repo", "some-org/some-repo",
+							"--token", "some-github-token",
+							"--ref-name", "main",
+							"--policy-file", ".github/semver-policy.yml",
+						)
+
+						buffer := gbytes.NewBuffer()
+
+						session, err := gexec.Start(command, buffer, buffer)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+						Expect(buffer).To(gbytes.Say(`invalid policy file: alias "breaking" refers to unknown label "semver:huge"`))
+					})
+				})
+			})
 		})
+
+		context("release lines", func() {
+			it.Before(func() {
+				repo.WithMergedPullRequest("sha-2", "Fixes a bug", "semver:patch").
+					WithBranch("v1.x", "sha-2").
+					WithBranch("legacy", "sha-2").
+					WithMergedPullRequest("sha-3", "Drops the v1 API", "semver:major").
+					WithRelease(githubtest.Release{TagName: "v2.0.0"})
+			})
+
+			it("infers the release line from the branch name", func() {
+				tag, buffer := calculate("--ref-name", "v1.x")
+				Expect(tag).To(Equal("tag=1.2.4"))
+				Expect(buffer).To(gbytes.Say(`Releasing v1.x on the 1.x release line`))
+				Expect(readReport()).To(ContainSubstring(`"release_line":"1.x"`))
+			})
+
+			it("uses the release line mapped to the branch", func() {
+				tag, _ := calculate("--ref-name", "legacy", "--release-lines", "some-branch=3.x, legacy=1.2.x")
+				Expect(tag).To(Equal("tag=1.2.4"))
+				Expect(readReport()).To(ContainSubstring(`"release_line":"1.2.x"`))
+			})
+
+			it("starts a release line without releases", func() {
+				repo.WithBranch("v1.3.x", "sha-2")
+
+				tag, _ := calculate("--ref-name", "v1.3.x")
+				Expect(tag).To(Equal("tag=1.3.0"))
+			})
+
+			it("releases across every line from other branches", func() {
+				repo.WithMergedPullRequest("sha-4", "Fixes another bug", "semver:patch")
+
+				tag, _ := calculate()
+				Expect(tag).To(Equal("tag=2.0.1"))
+			})
+
+			context("failure cases", func() {
+				context("when a bump would leave the release line", func() {
+					it.Before(func() {
+						repo.WithBranch("some-branch", "sha-3")
+					})
+
+					it("prints an error and exits non-zero", func() {
+						command := exec.Command(
+							entrypoint,
+							"--endpoint", server.URL,
+							"--repo", "some-org/some-repo",
+							"--token", "some-github-token",
+							"--ref-name", "some-branch",
+							"--release-lines", "some-branch=1.x",
+						)
+
+						buffer := gbytes.NewBuffer()
+
+						session, err := gexec.Start(command, buffer, buffer)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+						Expect(buffer).To(gbytes.Say(`the major bump from 1.2.3 to 2.0.0 would leave the 1.x release line of some-branch`))
+					})
+				})
+
+				context("when a release line mapping is malformed", func() {
+					it("prints an error and exits non-zero", func() {
+						command := exec.Command(
+							entrypoint,
+							"--endpoint", server.URL,
+							"--repo", "some-org/some-repo",
+							"--token", "some-github-token",
+							"--ref-name", "legacy",
+							"--release-lines", "legacy",
+						)
+
+						buffer := gbytes.NewBuffer()
+
+						session, err := gexec.Start(command, buffer, buffer)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+						Expect(buffer).To(gbytes.Say(`--release-lines entry "legacy" must be of the form branch=line`))
+					})
+				})
+
+				context("when a release line is invalid", func() {
+					it("prints an error and exits non-zero", func() {
+						command := exec.Command(
+							entrypoint,
+							"--endpoint", server.URL,
+							"--repo", "some-org/some-repo",
+							"--token", "some-github-token",
+							"--ref-name", "legacy",
+							"--release-lines", "legacy=one",
+						)
+
+						buffer := gbytes.NewBuffer()
+
+						session, err := gexec.Start(command, buffer, buffer)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+						Expect(buffer).To(gbytes.Say(`invalid release line "one", must be a major or major.minor version such as 1.x or 1.4.x`))
+					})
+				})
+			})
+		})
+
+		context("prerelease", func() {
+			it.Before(func() {
+				repo.WithMergedPullRequest("sha-2", "Adds a feature", "semver:minor")
+			})
+
+			it("produces the first prerelease of the next version", func() {
+				tag, _ := calculate("--prerelease", "rc")
+				Expect(tag).To(Equal("tag=1.3.0-rc.1"))
+			})
+
+			context("when there are prereleases of the next version", func() {
+				it.Before(func() {
+					repo.WithRelease(githubtest.Release{TagName: "v1.3.0-rc.1", Prerelease: true}).
+						WithRelease(githubtest.Release{TagName: "v1.3.0-rc.2", Prerelease: true}).
+						WithRelease(githubtest.Release{TagName: "v1.3.0-beta.7", Prerelease: true}).
+						WithRelease(githubtest.Release{TagName: "v1.3.0-rc.3", Draft: true})
+				})
+
+				it("counts up from the published prereleases with the same identifier", func() {
+					tag, _ := calculate("--prerelease", "rc")
+					Expect(tag).To(Equal("tag=1.3.0-rc.3"))
+				})
+
+				it("bases the next version on the latest final release", func() {
+					tag, buffer := calculate()
+					Expect(tag).To(Equal("tag=1.3.0"))
+					Expect(buffer).To(gbytes.Say(`Basing next semantic version off of 1.2.3`))
+				})
+			})
+
+			context("when the repo only has prereleases", func() {
+				it.Before(func() {
+					server.Close()
+					server = githubtest.NewServer()
+					server.AddRepo("some-org/some-repo").
+						WithCommit("sha-1", "Initial commit").
+						WithRelease(githubtest.Release{TagName: "v1.0.0-rc.1", Prerelease: true})
+				})
+
+				it("continues the version line of the prereleases", func() {
+					tag, _ := calculate("--prerelease", "rc")
+					Expect(tag).To(Equal("tag=1.0.0-rc.2"))
+				})
+			})
+
+			context("failure cases", func() {
+				context("when the prerelease identifier is invalid", func() {
+					it("prints an error and exits non-zero", func() {
+						command := exec.Command(
+							entrypoint,
+							"--endpoint", server.URL,
+							"--repo", "some-org/some-repo",
+							"--token", "some-github-token",
+							"--ref-name", "main",
+							"--prerelease", "rc_1",
+						)
+						command.Env = []string{
+							fmt.Sprintf("GITHUB_OUTPUT=%s", filepath.Join(tempDir, "github-output")),
+						}
+
+						buffer := gbytes.NewBuffer()
+
+						session, err := gexec.Start(command, buffer, buffer)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+						Expect(buffer).To(gbytes.Say(`--prerelease is not a valid prerelease identifier`))
+					})
+				})
+			})
+		})
+
+		context("promote", func() {
+			it.Before(func() {
+				repo.WithRelease(githubtest.Release{TagName: "v1.3.0-rc.1", Prerelease: true}).
+					WithRelease(githubtest.Release{TagName: "v1.3.0-rc.2", Prerelease: true})
+			})
+
+			it("turns the latest prerelease into the final version", func() {
+				tag, buffer := calculate("--promote")
+				Expect(tag).To(Equal("tag=1.3.0"))
+				Expect(buffer).To(gbytes.Say(`Promoting v1.3.0-rc.2`))
+			})
+
+			context("when the prerelease has already been released", func() {
+				it.Before(func() {
+					repo.WithRelease(githubtest.Release{TagName: "v1.3.0"})
+				})
+
+				it("prints an error and exits non-zero", func() {
+					command := exec.Command(
+						entrypoint,
+						"--endpoint", server.URL,
+						"--repo", "some-org/some-repo",
+						"--token", "some-github-token",
+						"--ref-name", "main",
+						"--promote",
+					)
+					command.Env = []string{
+						fmt.Sprintf("GITHUB_OUTPUT=%s", filepath.Join(tempDir, "github-output")),
+					}
+
+					buffer := gbytes.NewBuffer()
+
+					session, err := gexec.Start(command, buffer, buffer)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+					Expect(buffer).To(gbytes.Say(`no prerelease found to promote`))
+				})
+			})
+		})
+
+		context("build metadata", func() {
+			it("appends the metadata to the version", func() {
+				repo.WithMergedPullRequest("sha-2", "Fixes a bug", "semver:patch")
+
+				tag, _ := calculate("--prerelease", "rc", "--build-metadata", "build.42")
+				Expect(tag).To(Equal("tag=1.2.4-rc.1+build.42"))
+			})
+		})
+
+		context("when the release branch has more commits than a compare response holds", func() {
+			it.Before(func() {
+				for i := 2; i <= 300; i++ {
+					repo.WithMergedPullRequest(fmt.Sprintf("sha-%d", i), "Fixes a bug", "semver:patch")
+				}
+				repo.WithMergedPullRequest("sha-301", "Reworks the payload flag", "semver:major")
+			})
+
+			it("pages through every commit and looks up their PRs in batches", func() {
+				tag, _ := calculate()
+				Expect(tag).To(Equal("tag=2.0.0"))
+
+				var compares, queries int
+				for _, request := range server.Requests() {
+					switch {
+					case strings.Contains(request.Path, "/compare/"):
+						compares++
+					case request.Path == "/graphql":
+						queries++
+					case strings.HasSuffix(request.Path, "/pulls"):
+						t.Fatalf("unexpected request: %s", request.Path)
+					}
+				}
+
+				Expect(compares).To(Equal(3))
+				Expect(queries).To(Equal(6))
+			})
+
+			it("can look up PRs one commit at a time", func() {
+				tag, _ := calculate("--pull-request-lookup", "rest")
+				Expect(tag).To(Equal("tag=2.0.0"))
+
+				var pulls int
+				for _, request := range server.Requests() {
+					if strings.HasSuffix(request.Path, "/pulls") {
+						pulls++
+					}
+				}
+
+				Expect(pulls).To(Equal(300))
+			})
+		})
+
+		context("when the GraphQL query fails", func() {
+			it.Before(func() {
+				repo.WithMergedPullRequest("sha-2", "Fixes a bug", "semver:patch")
+
+				server.Override("POST", "/graphql", func(w http.ResponseWriter, req *http.Request) {
+					fmt.Fprintln(w, `{ "errors": [{ "type": "MAX_NODE_LIMIT_EXCEEDED", "message": "This query requests too many nodes." }] }`)
+				})
+			})
+
+			it("prints an error and exits non-zero", func() {
+				command := exec.Command(
+					entrypoint,
+					"--endpoint", server.URL,
+					"--repo", "some-org/some-repo",
+					"--token", "some-github-token",
+					"--ref-name", "main",
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+				Expect(buffer).To(gbytes.Say(`failed to get pull requests for commits: graphql: This query requests too many nodes.`))
+			})
+		})
+
+		context("when the pull request lookup is not supported", func() {
+			it("prints an error and exits non-zero", func() {
+				command := exec.Command(
+					entrypoint,
+					"--endpoint", server.URL,
+					"--repo", "some-org/some-repo",
+					"--token", "some-github-token",
+					"--ref-name", "main",
+					"--pull-request-lookup", "some-lookup",
+				)
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(1), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+				Expect(buffer).To(gbytes.Say(`unsupported pull request lookup "some-lookup", must be one of graphql or rest`))
+			})
+		})
+
+		context("report", func() {
+			it.Before(func() {
+				repo.WithMergedPullRequest("sha-2", "Fixes a bug", "semver:patch").
+					WithMergedPullRequest("sha-3", "Reworks the payload flag", "semver:major").
+					WithCommit("sha-4", "Direct push")
+			})
+
+			it("explains which changes drove the bump", func() {
+				tag, _ := calculate()
+				Expect(tag).To(Equal("tag=2.0.0"))
+
+				Expect(readReport()).To(MatchJSON(`{
+					"repo": "some-org/some-repo",
+					"mode": "labels",
+					"previous_version": "1.2.3",
+					"version": "2.0.0",
+					"bump": "major",
+					"reason": "the largest change since 1.2.3 was a major",
+					"driven_by": {"sha": "sha-3", "message": "Reworks the payload flag", "pull_request": 2, "labels": ["semver:major"], "size": "major", "source": "label"},
+					"changes": [
+						{"sha": "sha-2", "message": "Fixes a bug", "pull_request": 1, "labels": ["semver:patch"], "size": "patch", "source": "label"},
+						{"sha": "sha-3", "message": "Reworks the payload flag", "pull_request": 2, "labels": ["semver:major"], "size": "major", "source": "label"},
+						{"sha": "sha-4", "message": "Direct push", "labels": [], "size": "none", "source": "none"}
+					]
+				}`))
+			})
+
+			it("writes a markdown step summary", func() {
+				summaryPath := filepath.Join(tempDir, "step-summary")
+
+				command := exec.Command(
+					entrypoint,
+					"--endpoint", server.URL,
+					"--repo", "some-org/some-repo",
+					"--token", "some-github-token",
+					"--ref-name", "main",
+				)
+				command.Env = []string{
+					fmt.Sprintf("GITHUB_OUTPUT=%s", filepath.Join(tempDir, "github-output")),
+					fmt.Sprintf("GITHUB_STEP_SUMMARY=%s", summaryPath),
+				}
+
+				buffer := gbytes.NewBuffer()
+
+				session, err := gexec.Start(command, buffer, buffer)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output -> \n%s\n", buffer.Contents()) })
+
+				summary, err := os.ReadFile(summaryPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(summary)).To(Equal(`## Next version of some-org/some-repo: 2.0.0
+
+The largest change since 1.2.3 was a major.
+
+The major bump was driven by #2 (` + "`sha-3`" + `), labelled ` + "`semver:major`" + `.
+
+| Commit | Pull request | Labels | Size | Source |
+| --- | --- | --- | --- | --- |
+| ` + "`sha-2`" + ` Fixes a bug | #1 | ` + "`semver:patch`" + ` | patch | label |
+| ` + "`sha-3`" + ` Reworks the payload flag | #2 | ` + "`semver:major`" + ` | major | label |
+| ` + "`sha-4`" + ` Direct push |  |  | none | none |
+
+`))
+			})
+
+			context("when there are no releases", func() {
+				it.Before(func() {
+					server.Close()
+					server = githubtest.NewServer()
+					server.AddRepo("some-org/some-repo")
+				})
+
+				it("explains why", func() {
+					tag, _ := calculate()
+					Expect(tag).To(Equal("tag=0.0.1"))
+
+					Expect(readReport()).To(MatchJSON(`{
+						"repo": "some-org/some-repo",
+						"mode": "labels",
+						"version": "0.0.1",
+						"reason": "there are no published releases",
+						"changes": []
+					}`))
+				})
+			})
+		})
+	})
+}
+
+// serveCommitsPRs answers a GraphQL query for the PRs associated with commits
+// with the responses of rest to the REST requests for the PRs of each commit,
+// so that both lookups are served by the same fixtures. Failed and malformed
+// REST responses are passed on as they are.
+func serveCommitsPRs(w http.ResponseWriter, req *http.Request, rest http.Handler) {
+	var body struct {
+		Variables map[string]string `json:"variables"`
+	}
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	repository := map[string]interface{}{}
+	for alias, sha := range body.Variables {
+		if alias == "owner" || alias == "name" {
+			continue
+		}
+
+		recorder := httptest.NewRecorder()
+		rest.ServeHTTP(recorder, httptest.NewRequest("GET", fmt.Sprintf("/repos/%s/%s/commits/%s/pulls", body.Variables["owner"], body.Variables["name"], sha), nil))
+
+		var pulls []struct {
+			Number int `json:"number"`
+			Labels []struct {
+				Name string `json:"name"`
+			} `json:"labels"`
+			MergedAt string `json:"merged_at"`
+			User     struct {
+				Login string `json:"login"`
+				Type  string `json:"type"`
+			} `json:"user"`
+		}
+		if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &pulls) != nil {
+			w.WriteHeader(recorder.Code)
+			_, _ = w.Write(recorder.Body.Bytes())
+			return
+		}
+
+		nodes := []interface{}{}
+		for _, pull := range pulls {
+			labels := []interface{}{}
+			for _, label := range pull.Labels {
+				labels = append(labels, map[string]string{"name": label.Name})
+			}
+
+			var mergedAt interface{}
+			if pull.MergedAt != "" {
+				mergedAt = pull.MergedAt
+			}
+
+			nodes = append(nodes, map[string]interface{}{
+				"number":   pull.Number,
+				"mergedAt": mergedAt,
+				"author":   map[string]string{"login": pull.User.Login, "__typename": pull.User.Type},
+				"labels":   map[string]interface{}{"nodes": labels},
+			})
+		}
+
+		repository[alias] = map[string]interface{}{
+			"associatedPullRequests": map[string]interface{}{"nodes": nodes},
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": map[string]interface{}{"repository": repository},
 	})
 }
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/paketo-buildpacks/github-config/internal/github"
+	"go.yaml.in/yaml/v3"
+)
+
+// Policy decides how the labels of a PR size the change it merged. A policy
+// file in the repo looks like
+//
+//	labels:
+//	  semver:major: major
+//	  semver:minor: minor
+//	  semver:patch: patch
+//	aliases:
+//	  breaking: semver:major
+//	  dependencies: semver:patch
+//	exempt:
+//	  - skip-release
+//	bots:
+//	  default: patch
+//
+// Labels map a label to the size of the change. Aliases are labels that size
+// a change the same way as another label. PRs with an exempt label do not
+// contribute to the next version. Unlabeled PRs opened by a bot are given the
+// bots default size, when there is one.
+type Policy struct {
+	Labels  map[string]string `yaml:"labels"`
+	Aliases map[string]string `yaml:"aliases"`
+	Exempt  []string          `yaml:"exempt"`
+	Bots    struct {
+		Default string `yaml:"default"`
+	} `yaml:"bots"`
+}
+
+// DefaultPolicy sizes changes using the semver:patch, semver:minor and
+// semver:major labels.
+func DefaultPolicy() Policy {
+	return Policy{
+		Labels: map[string]string{
+			"semver:patch": "patch",
+			"semver:minor": "minor",
+			"semver:major": "major",
+		},
+	}
+}
+
+// parsePolicy reads a policy file, falling back to the default labels when
+// the file does not define any.
+func parsePolicy(content []byte) (Policy, error) {
+	var policy Policy
+	err := yaml.Unmarshal(content, &policy)
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+
+	if len(policy.Labels) == 0 {
+		policy.Labels = DefaultPolicy().Labels
+	}
+
+	for label, size := range policy.Labels {
+		if _, err := nameToSize(size); err != nil {
+			return Policy{}, fmt.Errorf("invalid policy file: label %q: %w", label, err)
+		}
+	}
+
+	for alias, label := range policy.Aliases {
+		if _, ok := policy.Labels[label]; !ok {
+			return Policy{}, fmt.Errorf("invalid policy file: alias %q refers to unknown label %q", alias, label)
+		}
+	}
+
+	if policy.Bots.Default != "" {
+		if _, err := nameToSize(policy.Bots.Default); err != nil {
+			return Policy{}, fmt.Errorf("invalid policy file: bots default: %w", err)
+		}
+	}
+
+	return policy, nil
+}
+
+// getPolicy reads the policy file at the given path from the repo at the
+// configured ref.
+func getPolicy(client *github.Client, config Config) (Policy, error) {
+	segments := strings.Split(config.PolicyFile, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	query := url.Values{"ref": {config.RefName}}
+	req, err := client.NewRequest("GET", fmt.Sprintf("/repos/%s/contents/%s?%s", config.Repo, strings.Join(segments, "/"), query.Encode()), nil)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	var file struct {
+		Encoding string `json:"encoding"`
+		Content  string `json:"content"`
+	}
+	_, err = client.Do(req, &file)
+	if err != nil {
+		var apiErr *github.Error
+		if errors.As(err, &apiErr) {
+			if apiErr.StatusCode == http.StatusNotFound {
+				return Policy{}, fmt.Errorf("policy file %s not found on %s", config.PolicyFile, config.RefName)
+			}
+			return Policy{}, fmt.Errorf("failed to get policy file: unexpected response: %w", err)
+		}
+		return Policy{}, err
+	}
+
+	if file.Encoding != "base64" {
+		return Policy{}, fmt.Errorf("failed to get policy file: unsupported encoding %q", file.Encoding)
+	}
+
+	// the API wraps the encoded content across lines
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", ""))
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to decode policy file: %w", err)
+	}
+
+	return parsePolicy(content)
+}
+
+// labelSize returns the size given by a label, following aliases.
+func (p Policy) labelSize(label string) (int, bool) {
+	if target, ok := p.Aliases[label]; ok {
+		label = target
+	}
+
+	name, ok := p.Labels[label]
+	if !ok {
+		return -1, false
+	}
+
+	size, err := nameToSize(name)
+	if err != nil {
+		return -1, false
+	}
+
+	return size, true
+}
+
+// isExempt reports whether the PR has a label that exempts it from sizing.
+func (p Policy) isExempt(pr PullRequest) bool {
+	for _, label := range pr.Labels {
+		if slices.Contains(p.Exempt, label.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// botSize returns the size given to an unlabeled PR opened by a bot.
+func (p Policy) botSize(pr PullRequest) (int, bool) {
+	if p.Bots.Default == "" || !pr.User.isBot() {
+		return -1, false
+	}
+
+	size, err := nameToSize(p.Bots.Default)
+	if err != nil {
+		return -1, false
+	}
+
+	return size, true
+}
+
+func nameToSize(name string) (int, error) {
+	switch name {
+	case "patch":
+		return PATCH, nil
+	case "minor":
+		return MINOR, nil
+	case "major":
+		return MAJOR, nil
+	default:
+		return -1, fmt.Errorf("unsupported size %q, must be one of patch, minor or major", name)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// SourceLabel means the size of a change was given by a semver PR label
+	SourceLabel = "label"
+
+	// SourceCommit means the size of a change was given by the type of its
+	// commit message
+	SourceCommit = "commit"
+
+	// SourceDefault means the change was an unlabeled bot PR given the
+	// default size of the policy
+	SourceDefault = "default"
+
+	// SourceNone means the change did not contribute to the next version
+	SourceNone = "none"
+)
+
+// Change is a commit since the last release, along with the PR that merged
+// it, and the size it contributed to the next version.
+type Change struct {
+	SHA         string   `json:"sha"`
+	Message     string   `json:"message"`
+	PullRequest int      `json:"pull_request,omitempty"`
+	Labels      []string `json:"labels"`
+	Size        string   `json:"size"`
+	Source      string   `json:"source"`
+
+	size  int
+	label string
+}
+
+// Report explains how the next version was calculated.
+type Report struct {
+	Repo            string   `json:"repo"`
+	Mode            string   `json:"mode"`
+	ReleaseLine     string   `json:"release_line,omitempty"`
+	PreviousVersion string   `json:"previous_version,omitempty"`
+	Version         string   `json:"version"`
+	Bump            string   `json:"bump,omitempty"`
+	Reason          string   `json:"reason"`
+	DrivenBy        *Change  `json:"driven_by,omitempty"`
+	Changes         []Change `json:"changes"`
+}
+
+func newChange(commit Commit, pr *PullRequest, size int, source string) Change {
+	subject, _, _ := strings.Cut(commit.Commit.Message, "\n")
+
+	change := Change{
+		SHA:     commit.SHA,
+		Message: strings.TrimSpace(subject),
+		Labels:  []string{},
+		Size:    sizeToName(size),
+		Source:  source,
+		size:    size,
+	}
+
+	if pr != nil {
+		change.PullRequest = pr.Number
+		for _, label := range pr.Labels {
+			change.Labels = append(change.Labels, label.Name)
+		}
+	}
+
+	return change
+}
+
+func sizeToName(size int) string {
+	switch size {
+	case PATCH:
+		return "patch"
+	case MINOR:
+		return "minor"
+	case MAJOR:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// drivingChange returns the change that contributed the largest size, the
+// earliest one winning ties. It returns nil when no change contributed.
+func drivingChange(changes []Change) *Change {
+	var largest *Change
+	for i, change := range changes {
+		if change.Source == SourceNone {
+			continue
+		}
+
+		if largest == nil || change.size > largest.size {
+			largest = &changes[i]
+		}
+	}
+
+	return largest
+}
+
+// writeReportOutput writes the report as JSON to the report output and, when
+// running in a workflow, as markdown to the step summary.
+func writeReportOutput(report Report) {
+	if report.Changes == nil {
+		report.Changes = []Change{}
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		fail(fmt.Errorf("failed to encode report: %w", err))
+	}
+
+	outputFileName, ok := os.LookupEnv("GITHUB_OUTPUT")
+	if !ok {
+		fail(errors.New("GITHUB_OUTPUT is not set, see https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#setting-an-output-parameter"))
+	}
+
+	file, err := os.OpenFile(outputFileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		fail(err)
+	}
+	defer file.Close()
+	fmt.Fprintf(file, "report=%s\n", data)
+
+	summaryFileName, ok := os.LookupEnv("GITHUB_STEP_SUMMARY")
+	if !ok || summaryFileName == "" {
+		return
+	}
+
+	summary, err := os.OpenFile(summaryFileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		fail(err)
+	}
+	defer summary.Close()
+	fmt.Fprint(summary, report.Markdown())
+}
+
+// Markdown renders the report for a workflow step summary.
+func (r Report) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "## Next version of %s: %s\n\n", r.Repo, r.Version)
+	fmt.Fprintf(&b, "%s.\n", capitalize(r.Reason))
+
+	if r.DrivenBy != nil {
+		fmt.Fprintf(&b, "\nThe %s bump was driven by %s.\n", r.Bump, r.DrivenBy.describe())
+	}
+
+	if len(r.Changes) > 0 {
+		fmt.Fprintf(&b, "\n| Commit | Pull request | Labels | Size | Source |\n")
+		fmt.Fprintf(&b, "| --- | --- | --- | --- | --- |\n")
+		for _, change := range r.Changes {
+			pr := ""
+			if change.PullRequest != 0 {
+				pr = fmt.Sprintf("#%d", change.PullRequest)
+			}
+
+			var labels []string
+			for _, label := range change.Labels {
+				labels = append(labels, fmt.Sprintf("`%s`", label))
+			}
+
+			fmt.Fprintf(&b, "| `%s` %s | %s | %s | %s | %s |\n",
+				shortSHA(change.SHA),
+				strings.ReplaceAll(change.Message, "|", `\|`),
+				pr,
+				strings.Join(labels, " "),
+				change.Size,
+				change.Source,
+			)
+		}
+	}
+
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (c Change) describe() string {
+	switch {
+	case c.PullRequest != 0 && c.Source == SourceLabel:
+		return fmt.Sprintf("#%d (`%s`), labelled `%s`", c.PullRequest, shortSHA(c.SHA), c.label)
+	case c.PullRequest != 0 && c.Source == SourceDefault:
+		return fmt.Sprintf("#%d (`%s`), the default for unlabeled bot PRs", c.PullRequest, shortSHA(c.SHA))
+	case c.PullRequest != 0:
+		return fmt.Sprintf("#%d (`%s`), from its commit message %q", c.PullRequest, shortSHA(c.SHA), c.Message)
+	default:
+		return fmt.Sprintf("`%s`, from its commit message %q", shortSHA(c.SHA), c.Message)
+	}
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+
+	return sha
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+
+	return strings.ToUpper(s[:1]) + s[1:]
+}
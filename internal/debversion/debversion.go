@@ -0,0 +1,169 @@
+// Package debversion compares Debian package versions the same way as
+// dpkg --compare-versions.
+package debversion
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// debianVersion is a Debian package version of the form
+// [epoch:]upstream_version[-debian_revision], see
+// https://www.debian.org/doc/debian-policy/ch-controlfields.html#version.
+type debianVersion struct {
+	epoch    int
+	upstream string
+	revision string
+}
+
+func parseDebianVersion(version string) (debianVersion, error) {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return debianVersion{}, fmt.Errorf("invalid Debian version %q: version is empty", version)
+	}
+
+	original := version
+
+	var parsed debianVersion
+	if epoch, rest, ok := strings.Cut(version, ":"); ok {
+		n, err := strconv.Atoi(epoch)
+		if err != nil || n < 0 {
+			return debianVersion{}, fmt.Errorf("invalid Debian version %q: epoch is not a number", original)
+		}
+		parsed.epoch = n
+		version = rest
+	}
+
+	parsed.upstream = version
+	if i := strings.LastIndex(version, "-"); i >= 0 {
+		parsed.upstream = version[:i]
+		parsed.revision = version[i+1:]
+	}
+
+	if parsed.upstream == "" || !isDigit(parsed.upstream[0]) {
+		return debianVersion{}, fmt.Errorf("invalid Debian version %q: upstream version must start with a digit", original)
+	}
+
+	return parsed, nil
+}
+
+// Compare returns -1, 0 or 1 when a is older than, the same as or newer than
+// b, using the same ordering as dpkg --compare-versions. It returns an error
+// when either is not a valid Debian version.
+func Compare(a, b string) (int, error) {
+	va, err := parseDebianVersion(a)
+	if err != nil {
+		return 0, err
+	}
+
+	vb, err := parseDebianVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case va.epoch < vb.epoch:
+		return -1, nil
+	case va.epoch > vb.epoch:
+		return 1, nil
+	}
+
+	if result := compareVersionPart(va.upstream, vb.upstream); result != 0 {
+		return result, nil
+	}
+
+	return compareVersionPart(va.revision, vb.revision), nil
+}
+
+// compareVersionPart compares upstream versions or revisions by alternating
+// between runs of non-digits, compared by their modified ASCII order, and
+// runs of digits, compared numerically.
+func compareVersionPart(a, b string) int {
+	for a != "" || b != "" {
+		var nonDigitsA, nonDigitsB string
+		nonDigitsA, a = splitRun(a, false)
+		nonDigitsB, b = splitRun(b, false)
+
+		if result := compareNonDigits(nonDigitsA, nonDigitsB); result != 0 {
+			return result
+		}
+
+		var digitsA, digitsB string
+		digitsA, a = splitRun(a, true)
+		digitsB, b = splitRun(b, true)
+
+		if result := compareDigits(digitsA, digitsB); result != 0 {
+			return result
+		}
+	}
+
+	return 0
+}
+
+func splitRun(s string, digits bool) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) == digits {
+		i++
+	}
+
+	return s[:i], s[i:]
+}
+
+func compareNonDigits(a, b string) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var ca, cb int
+		if i < len(a) {
+			ca = order(a[i])
+		}
+		if i < len(b) {
+			cb = order(b[i])
+		}
+
+		switch {
+		case ca < cb:
+			return -1
+		case ca > cb:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func compareDigits(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// order sorts a tilde before the end of a part, which sorts before letters,
+// which sort before every other character.
+func order(c byte) int {
+	switch {
+	case c == '~':
+		return -1
+	case isDigit(c):
+		return 0
+	case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		return int(c)
+	default:
+		return int(c) + 256
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
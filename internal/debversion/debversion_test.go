@@ -0,0 +1,77 @@
+package debversion_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/github-config/internal/debversion"
+)
+
+func testCompare(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	it("orders versions the same way as dpkg --compare-versions", func() {
+		for _, c := range []struct {
+			a, b   string
+			result int
+		}{
+			{"1.0", "1.0", 0},
+			{"1.0", "1.1", -1},
+			{"1.10", "1.9", 1},
+			{"010", "10", 0},
+
+			// epochs take precedence over the rest of the version, and a
+			// missing epoch is 0
+			{"1:1.0", "2.0", 1},
+			{"0:1.0", "1.0", 0},
+			{"2:1.0", "10:0.1", -1},
+
+			// a tilde sorts before everything, even the end of the version
+			{"1.0~rc1", "1.0", -1},
+			{"1.0~rc1", "1.0~rc2", -1},
+			{"1.0~~", "1.0~", -1},
+			{"3.0.18-1~deb12u1", "3.0.18-1", -1},
+
+			// letters sort before other characters
+			{"1.0a", "1.0+", -1},
+			{"1.0+dfsg", "1.0", 1},
+
+			// revisions are compared after the upstream versions, which may
+			// hold hyphens themselves
+			{"1.0-1", "1.0-2", -1},
+			{"1.0-1ubuntu1", "1.0-1", 1},
+			{"2.36-9+deb12u10", "2.36-9+deb12u9", 1},
+			{"1.2.3-1-1", "1.2.3-1-2", -1},
+			{"1.0", "1.0-0", 0},
+		} {
+			result, err := debversion.Compare(c.a, c.b)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(c.result), "comparing %q with %q", c.a, c.b)
+
+			result, err = debversion.Compare(c.b, c.a)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(-c.result), "comparing %q with %q", c.b, c.a)
+		}
+	})
+
+	context("failure cases", func() {
+		it("returns an error when a version is not a Debian version", func() {
+			for _, c := range []struct {
+				a, b string
+				err  string
+			}{
+				{"", "1.0", `invalid Debian version "": version is empty`},
+				{"1.0", "  ", `invalid Debian version "": version is empty`},
+				{"x:1.0", "1.0", `invalid Debian version "x:1.0": epoch is not a number`},
+				{"-1:1.0", "1.0", `invalid Debian version "-1:1.0": epoch is not a number`},
+				{"1.0", "latest", `invalid Debian version "latest": upstream version must start with a digit`},
+				{"1:-1", "1.0", `invalid Debian version "1:-1": upstream version must start with a digit`},
+			} {
+				_, err := debversion.Compare(c.a, c.b)
+				Expect(err).To(MatchError(c.err), "comparing %q with %q", c.a, c.b)
+			}
+		})
+	})
+}
@@ -0,0 +1,14 @@
+package debversion_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestDebversion(t *testing.T) {
+	suite := spec.New("internal/debversion", spec.Report(report.Terminal{}))
+	suite("Compare", testCompare)
+	suite.Run(t)
+}
@@ -0,0 +1,206 @@
+package github
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// installationTokenRefreshWindow is how long before expiry an installation
+// token is replaced, so that long-running requests never carry a token that
+// expires mid-flight.
+const installationTokenRefreshWindow = 5 * time.Minute
+
+// TokenSource supplies the token used to authenticate each request.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+type staticTokenSource string
+
+func (s staticTokenSource) Token() (string, error) {
+	return string(s), nil
+}
+
+// AppCredentials identify a GitHub App installation that requests are made
+// on behalf of.
+type AppCredentials struct {
+	AppID          string
+	InstallationID string
+	PrivateKey     string
+}
+
+// IsSet reports whether any of the credentials have been provided.
+func (a AppCredentials) IsSet() bool {
+	return a.AppID != "" || a.InstallationID != "" || a.PrivateKey != ""
+}
+
+// AppTokenSource mints installation access tokens for a GitHub App, as
+// described in
+// https://docs.github.com/en/apps/creating-github-apps/authenticating-with-a-github-app/authenticating-as-a-github-app-installation.
+// Tokens are cached and refreshed shortly before they expire.
+type AppTokenSource struct {
+	endpoint       string
+	appID          string
+	installationID string
+	privateKey     *rsa.PrivateKey
+	httpClient     *http.Client
+
+	m         sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func NewAppTokenSource(endpoint string, credentials AppCredentials, httpClient *http.Client) (*AppTokenSource, error) {
+	if credentials.AppID == "" {
+		return nil, errors.New("missing GitHub App ID")
+	}
+
+	if credentials.InstallationID == "" {
+		return nil, errors.New("missing GitHub App installation ID")
+	}
+
+	privateKey, err := parsePrivateKey([]byte(credentials.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &AppTokenSource{
+		endpoint:       strings.TrimSuffix(endpoint, "/"),
+		appID:          credentials.AppID,
+		installationID: credentials.InstallationID,
+		privateKey:     privateKey,
+		httpClient:     httpClient,
+	}, nil
+}
+
+// WithAppCredentials authenticates every request using an installation token
+// minted for the given GitHub App installation.
+func WithAppCredentials(credentials AppCredentials) (Option, error) {
+	// The endpoint and http.Client of the source are replaced with those of
+	// the client once all options have been applied
+	source, err := NewAppTokenSource(DefaultEndpoint, credentials, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *Client) {
+		c.appTokenSource = source
+	}, nil
+}
+
+func (s *AppTokenSource) Token() (string, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.token != "" && time.Until(s.expiresAt) > installationTokenRefreshWindow {
+		return s.token, nil
+	}
+
+	jwt, err := s.jwt(time.Now())
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/app/installations/%s/access_tokens", s.endpoint, s.installationID), nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", jwt))
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create installation token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("failed to create installation token: %w", newError(resp))
+	}
+
+	var installationToken struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&installationToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse installation token response: %w", err)
+	}
+
+	s.token = installationToken.Token
+	s.expiresAt = installationToken.ExpiresAt
+
+	return s.token, nil
+}
+
+// jwt returns an RS256-signed JSON Web Token identifying the app, as
+// described in
+// https://docs.github.com/en/apps/creating-github-apps/authenticating-with-a-github-app/generating-a-json-web-token-jwt-for-a-github-app.
+func (s *AppTokenSource) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	// Issue the token in the past to allow for clock drift, and expire it well
+	// within GitHub's ten minute limit
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": s.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := fmt.Sprintf("%s.%s", base64.RawURLEncoding.EncodeToString(header), base64.RawURLEncoding.EncodeToString(claims))
+
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s.%s", unsigned, base64.RawURLEncoding.EncodeToString(signature)), nil
+}
+
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(bytes.TrimSpace(data))
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+
+	return rsaKey, nil
+}
@@ -0,0 +1,263 @@
+package github_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/github-config/internal/github"
+)
+
+func testApp(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		api        *httptest.Server
+		privateKey *rsa.PrivateKey
+		privatePEM string
+		expiresIn  time.Duration
+		minted     int
+		apiTokens  []string
+	)
+
+	it.Before(func() {
+		var err error
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+
+		privatePEM = string(pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+		}))
+
+		expiresIn = time.Hour
+		minted = 0
+		apiTokens = nil
+
+		api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			switch req.URL.Path {
+			case "/app/installations/some-installation-id/access_tokens":
+				if req.Method != http.MethodPost {
+					t.Fatalf("unexpected method: %s", req.Method)
+				}
+
+				jwt, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+				if !ok || !validJWT(&privateKey.PublicKey, jwt, "some-app-id") {
+					w.WriteHeader(http.StatusUnauthorized)
+					fmt.Fprintln(w, `{ "message": "A JSON web token could not be decoded" }`)
+					return
+				}
+
+				minted++
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintf(w, `{ "token": "installation-token-%d", "expires_at": %q }`, minted, time.Now().Add(expiresIn).UTC().Format(time.RFC3339))
+
+			case "/app/installations/missing-installation-id/access_tokens":
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintln(w, `{ "message": "Not Found" }`)
+
+			case "/repos/some-org/some-repo":
+				apiTokens = append(apiTokens, req.Header.Get("Authorization"))
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintln(w, `{}`)
+
+			default:
+				t.Fatalf("unknown path: %s", req.URL.Path)
+			}
+		}))
+	})
+
+	it.After(func() {
+		api.Close()
+	})
+
+	context("WithAppCredentials", func() {
+		it("authenticates requests with a minted installation token", func() {
+			auth, err := github.WithAppCredentials(github.AppCredentials{
+				AppID:          "some-app-id",
+				InstallationID: "some-installation-id",
+				PrivateKey:     privatePEM,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			client := github.NewClient(api.URL, auth)
+
+			for i := 0; i < 2; i++ {
+				req, err := client.NewRequest("GET", "/repos/some-org/some-repo", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = client.Do(req, nil)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			Expect(minted).To(Equal(1))
+			Expect(apiTokens).To(Equal([]string{"token installation-token-1", "token installation-token-1"}))
+		})
+
+		it("accepts PKCS8 encoded private keys", func() {
+			der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = github.WithAppCredentials(github.AppCredentials{
+				AppID:          "some-app-id",
+				InstallationID: "some-installation-id",
+				PrivateKey:     string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		context("failure cases", func() {
+			context("when the app ID is missing", func() {
+				it("returns an error", func() {
+					_, err := github.WithAppCredentials(github.AppCredentials{
+						InstallationID: "some-installation-id",
+						PrivateKey:     privatePEM,
+					})
+					Expect(err).To(MatchError("missing GitHub App ID"))
+				})
+			})
+
+			context("when the installation ID is missing", func() {
+				it("returns an error", func() {
+					_, err := github.WithAppCredentials(github.AppCredentials{
+						AppID:      "some-app-id",
+						PrivateKey: privatePEM,
+					})
+					Expect(err).To(MatchError("missing GitHub App installation ID"))
+				})
+			})
+
+			context("when the private key is not PEM encoded", func() {
+				it("returns an error", func() {
+					_, err := github.WithAppCredentials(github.AppCredentials{
+						AppID:          "some-app-id",
+						InstallationID: "some-installation-id",
+						PrivateKey:     "not-a-key",
+					})
+					Expect(err).To(MatchError("failed to parse GitHub App private key: no PEM data found"))
+				})
+			})
+		})
+	})
+
+	context("AppTokenSource", func() {
+		var source *github.AppTokenSource
+
+		it.Before(func() {
+			var err error
+			source, err = github.NewAppTokenSource(api.URL, github.AppCredentials{
+				AppID:          "some-app-id",
+				InstallationID: "some-installation-id",
+				PrivateKey:     privatePEM,
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		context("when the token is close to expiring", func() {
+			it.Before(func() {
+				expiresIn = 2 * time.Minute
+			})
+
+			it("mints a new token", func() {
+				token, err := source.Token()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(token).To(Equal("installation-token-1"))
+
+				token, err = source.Token()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(token).To(Equal("installation-token-2"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the installation does not exist", func() {
+				it.Before(func() {
+					var err error
+					source, err = github.NewAppTokenSource(api.URL, github.AppCredentials{
+						AppID:          "some-app-id",
+						InstallationID: "missing-installation-id",
+						PrivateKey:     privatePEM,
+					}, nil)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				it("returns an error", func() {
+					_, err := source.Token()
+					Expect(err).To(MatchError(ContainSubstring("failed to create installation token: POST")))
+					Expect(err).To(MatchError(ContainSubstring("404 Not Found: Not Found")))
+				})
+			})
+
+			context("when the JWT is signed with a different key", func() {
+				it.Before(func() {
+					otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+					Expect(err).NotTo(HaveOccurred())
+
+					source, err = github.NewAppTokenSource(api.URL, github.AppCredentials{
+						AppID:          "some-app-id",
+						InstallationID: "some-installation-id",
+						PrivateKey: string(pem.EncodeToMemory(&pem.Block{
+							Type:  "RSA PRIVATE KEY",
+							Bytes: x509.MarshalPKCS1PrivateKey(otherKey),
+						})),
+					}, nil)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				it("returns an error", func() {
+					_, err := source.Token()
+					Expect(err).To(MatchError(ContainSubstring("401 Unauthorized: A JSON web token could not be decoded")))
+				})
+			})
+		})
+	})
+}
+
+// validJWT verifies the signature and issuer of an RS256 JSON Web Token.
+func validJWT(key *rsa.PublicKey, jwt, issuer string) bool {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return false
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+		return false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+
+	var claims struct {
+		Issuer    string `json:"iss"`
+		IssuedAt  int64  `json:"iat"`
+		ExpiresAt int64  `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil {
+		return false
+	}
+
+	now := time.Now().Unix()
+	return claims.Issuer == issuer && claims.IssuedAt <= now && claims.ExpiresAt > now
+}
@@ -0,0 +1,164 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const DefaultEndpoint = "https://api.github.com"
+
+// Client sends authenticated requests to the GitHub REST API. Paths given to
+// NewRequest that begin with a slash are resolved against the configured
+// endpoint, anything else is used as a full URL, such as the upload or
+// download URLs returned by the API.
+type Client struct {
+	endpoint       string
+	tokenSource    TokenSource
+	appTokenSource *AppTokenSource
+	httpClient     *http.Client
+	rateLimitWait  time.Duration
+}
+
+type Option func(*Client)
+
+// WithToken authenticates every request using the given personal access or
+// installation token.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.tokenSource = staticTokenSource(token)
+	}
+}
+
+// WithTokenSource authenticates every request using a token retrieved from
+// the given source.
+func WithTokenSource(source TokenSource) Option {
+	return func(c *Client) {
+		c.tokenSource = source
+	}
+}
+
+// WithHTTPClient replaces the http.Client used to send requests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRateLimitWait waits out rate-limited responses and retries them, for at
+// most maxWait in total across every request made by the client.
+func WithRateLimitWait(maxWait time.Duration) Option {
+	return func(c *Client) {
+		c.rateLimitWait = maxWait
+	}
+}
+
+func NewClient(endpoint string, options ...Option) *Client {
+	client := &Client{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		httpClient: http.DefaultClient,
+	}
+
+	for _, option := range options {
+		option(client)
+	}
+
+	if client.rateLimitWait > 0 {
+		httpClient := *client.httpClient
+		httpClient.Transport = NewRateLimitTransport(httpClient.Transport, client.rateLimitWait, os.Stdout)
+		client.httpClient = &httpClient
+	}
+
+	if client.appTokenSource != nil {
+		client.appTokenSource.endpoint = client.endpoint
+		client.appTokenSource.httpClient = client.httpClient
+		client.tokenSource = client.appTokenSource
+	}
+
+	return client
+}
+
+// Response wraps the http.Response returned by the API and exposes the URL of
+// the next page of results, when the response is paginated.
+type Response struct {
+	*http.Response
+
+	NextURL string
+}
+
+func (c *Client) NewRequest(method, path string, body io.Reader) (*http.Request, error) {
+	uri := path
+	if strings.HasPrefix(path, "/") {
+		uri = c.endpoint + path
+	}
+
+	req, err := http.NewRequest(method, uri, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.tokenSource != nil {
+		token, err := c.tokenSource.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		if token != "" {
+			req.Header.Set("Authorization", fmt.Sprintf("token %s", token))
+		}
+	}
+
+	return req, nil
+}
+
+// Do sends the request and closes the response body. When v is an io.Writer
+// the body is copied into it, otherwise it is decoded as JSON into v. A nil v
+// discards the body. Responses with a non-2xx status are returned as *Error.
+func (c *Client) Do(req *http.Request, v interface{}) (*Response, error) {
+	resp, err := c.BareDo(req)
+	if err != nil {
+		return resp, err
+	}
+	defer resp.Body.Close()
+
+	switch v := v.(type) {
+	case nil:
+		_, err = io.Copy(io.Discard, resp.Body)
+	case io.Writer:
+		_, err = io.Copy(v, resp.Body)
+	default:
+		err = json.NewDecoder(resp.Body).Decode(v)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	}
+
+	return resp, err
+}
+
+// BareDo sends the request and returns the response with its body left open
+// for the caller to consume and close. Responses with a non-2xx status are
+// returned as *Error and have their body closed.
+func (c *Client) BareDo(req *http.Request) (*Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Response{
+		Response: resp,
+		NextURL:  parseNextLink(resp.Header.Get("Link")),
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return response, newError(resp)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,180 @@
+package github_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/github-config/internal/github"
+)
+
+func testClient(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		api      *httptest.Server
+		requests []*http.Request
+		client   *github.Client
+	)
+
+	it.Before(func() {
+		requests = nil
+		api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			requests = append(requests, req)
+
+			switch req.URL.Path {
+			case "/repos/some-org/some-repo":
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintln(w, `{ "full_name": "some-org/some-repo" }`)
+
+			case "/repos/some-org/some-repo/dispatches":
+				w.WriteHeader(http.StatusNoContent)
+
+			case "/repos/some-org/some-repo/download":
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, "some-content")
+
+			case "/repos/some-org/missing-repo":
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintln(w, `{ "message": "Not Found", "documentation_url": "https://docs.github.com/rest", "status": "404" }`)
+
+			case "/repos/some-org/broken-repo":
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprintln(w, `{"error": "server-error"}`)
+
+			default:
+				t.Fatalf("unknown path: %s", req.URL.Path)
+			}
+		}))
+
+		client = github.NewClient(api.URL+"/", github.WithToken("some-token"))
+	})
+
+	it.After(func() {
+		api.Close()
+	})
+
+	context("NewRequest", func() {
+		it("resolves the path against the endpoint and sets the authorization header", func() {
+			req, err := client.NewRequest("GET", "/repos/some-org/some-repo", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(req.URL.String()).To(Equal(api.URL + "/repos/some-org/some-repo"))
+			Expect(req.Header.Get("Authorization")).To(Equal("token some-token"))
+		})
+
+		context("when the path is an absolute URL", func() {
+			it("uses the URL as-is", func() {
+				req, err := client.NewRequest("POST", "https://uploads.github.com/repos/some-org/some-repo/releases/1/assets", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(req.URL.String()).To(Equal("https://uploads.github.com/repos/some-org/some-repo/releases/1/assets"))
+				Expect(req.Header.Get("Authorization")).To(Equal("token some-token"))
+			})
+		})
+
+		context("when no token is configured", func() {
+			it("does not set the authorization header", func() {
+				req, err := github.NewClient(api.URL).NewRequest("GET", "/repos/some-org/some-repo", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(req.Header).NotTo(HaveKey("Authorization"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the endpoint is malformed", func() {
+				it("returns an error", func() {
+					_, err := github.NewClient("%%%").NewRequest("GET", "/repos/some-org/some-repo", nil)
+					Expect(err).To(MatchError(`parse "%%%/repos/some-org/some-repo": invalid URL escape "%%%"`))
+				})
+			})
+		})
+	})
+
+	context("Do", func() {
+		it("decodes the JSON response into the given value", func() {
+			req, err := client.NewRequest("GET", "/repos/some-org/some-repo", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			var repo struct {
+				FullName string `json:"full_name"`
+			}
+			resp, err := client.Do(req, &repo)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(repo.FullName).To(Equal("some-org/some-repo"))
+		})
+
+		context("when the response has no content", func() {
+			it("succeeds", func() {
+				req, err := client.NewRequest("POST", "/repos/some-org/some-repo/dispatches", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				var v map[string]interface{}
+				resp, err := client.Do(req, &v)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+			})
+		})
+
+		context("when given an io.Writer", func() {
+			it("copies the response body into it", func() {
+				req, err := client.NewRequest("GET", "/repos/some-org/some-repo/download", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				buffer := bytes.NewBuffer(nil)
+				_, err = client.Do(req, buffer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(buffer.String()).To(Equal("some-content"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the API returns an error document", func() {
+				it("returns a typed error", func() {
+					req, err := client.NewRequest("GET", "/repos/some-org/missing-repo", nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = client.Do(req, nil)
+					Expect(err).To(MatchError(fmt.Sprintf("GET %s/repos/some-org/missing-repo: 404 Not Found: Not Found", api.URL)))
+
+					var apiErr *github.Error
+					Expect(errors.As(err, &apiErr)).To(BeTrue())
+					Expect(apiErr.StatusCode).To(Equal(http.StatusNotFound))
+					Expect(apiErr.Status).To(Equal("404 Not Found"))
+					Expect(apiErr.Message).To(Equal("Not Found"))
+					Expect(apiErr.DocumentationURL).To(Equal("https://docs.github.com/rest"))
+				})
+			})
+
+			context("when the API returns some other error body", func() {
+				it("includes the body in the error", func() {
+					req, err := client.NewRequest("GET", "/repos/some-org/broken-repo", nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = client.Do(req, nil)
+					Expect(err).To(MatchError(fmt.Sprintf(`GET %s/repos/some-org/broken-repo: 500 Internal Server Error: {"error": "server-error"}`, api.URL)))
+				})
+			})
+
+			context("when the response cannot be decoded", func() {
+				it("returns an error", func() {
+					req, err := client.NewRequest("GET", "/repos/some-org/some-repo/download", nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					var v map[string]interface{}
+					_, err = client.Do(req, &v)
+					Expect(err).To(MatchError(ContainSubstring("invalid character")))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,52 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Error describes a response from the API with a non-2xx status code.
+type Error struct {
+	Method     string      `json:"-"`
+	URL        string      `json:"-"`
+	StatusCode int         `json:"-"`
+	Status     string      `json:"-"`
+	Header     http.Header `json:"-"`
+	Body       []byte      `json:"-"`
+
+	// Message and DocumentationURL are populated when the body is a GitHub
+	// error document.
+	Message          string `json:"message"`
+	DocumentationURL string `json:"documentation_url"`
+}
+
+func newError(resp *http.Response) *Error {
+	apiErr := &Error{
+		Method:     resp.Request.Method,
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+	}
+
+	apiErr.Body, _ = io.ReadAll(resp.Body)
+	_ = json.Unmarshal(apiErr.Body, apiErr)
+
+	return apiErr
+}
+
+func (e *Error) Error() string {
+	detail := e.Message
+	if detail == "" {
+		detail = strings.TrimSpace(string(e.Body))
+	}
+
+	if detail == "" {
+		return fmt.Sprintf("%s %s: %s", e.Method, e.URL, e.Status)
+	}
+
+	return fmt.Sprintf("%s %s: %s: %s", e.Method, e.URL, e.Status, detail)
+}
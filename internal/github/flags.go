@@ -0,0 +1,84 @@
+package github
+
+import (
+	"flag"
+	"os"
+	"time"
+)
+
+// ClientEnv names the environment variables that credentials are read from
+// when they are not given as flags. Names left empty are not read.
+type ClientEnv struct {
+	Token          string
+	AppID          string
+	InstallationID string
+	PrivateKey     string
+}
+
+// ActionClientEnv reads the private key of a GitHub App from APP_PRIVATE_KEY,
+// which each action that authenticates as an App sets from its private key
+// input in the env of its runs section, whatever the style of its input names.
+var ActionClientEnv = ClientEnv{PrivateKey: "APP_PRIVATE_KEY"}
+
+// ClientFlags are the flags that configure how an entrypoint authenticates
+// to the API and how long it waits out rate limits.
+//
+// The private key of a GitHub App is only read from the environment, such as
+// a variable a Docker action sets from one of its inputs, as the arguments of
+// a Docker action are visible in the process list and logged as part of its
+// docker run command line.
+type ClientFlags struct {
+	Token            string
+	RateLimitMaxWait time.Duration
+	App              AppCredentials
+}
+
+// RegisterClientFlags registers --token, --rate-limit-max-wait, --app-id and
+// --app-installation-id on the flag set.
+func RegisterClientFlags(flags *flag.FlagSet, env ClientEnv, rateLimitMaxWait time.Duration) *ClientFlags {
+	f := &ClientFlags{}
+
+	flags.StringVar(&f.Token, "token", "", "GitHub token, used unless GitHub App credentials are given")
+	flags.DurationVar(&f.RateLimitMaxWait, "rate-limit-max-wait", rateLimitMaxWait, "Longest total time to wait out GitHub API rate limits")
+	flags.StringVar(&f.App.AppID, "app-id", "", "GitHub App ID, used instead of token")
+	flags.StringVar(&f.App.InstallationID, "app-installation-id", "", "GitHub App installation ID, used instead of token")
+
+	// The environment is read after the flags are registered, so that usage
+	// does not print credentials as defaults
+	f.Token = getenv(env.Token)
+	f.App.AppID = getenv(env.AppID)
+	f.App.InstallationID = getenv(env.InstallationID)
+	f.App.PrivateKey = getenv(env.PrivateKey)
+
+	return f
+}
+
+// HasCredentials reports whether a token or any GitHub App credentials were
+// given.
+func (f *ClientFlags) HasCredentials() bool {
+	return f.Token != "" || f.App.IsSet()
+}
+
+// NewClient returns a Client for the endpoint that authenticates as the
+// GitHub App installation when any of its credentials were given, and with
+// the token otherwise.
+func (f *ClientFlags) NewClient(endpoint string) (*Client, error) {
+	auth := WithToken(f.Token)
+	if f.App.IsSet() {
+		var err error
+		auth, err = WithAppCredentials(f.App)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewClient(endpoint, auth, WithRateLimitWait(f.RateLimitMaxWait)), nil
+}
+
+func getenv(name string) string {
+	if name == "" {
+		return ""
+	}
+
+	return os.Getenv(name)
+}
@@ -0,0 +1,180 @@
+package github_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/github-config/internal/github"
+)
+
+func testFlags(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		flags *flag.FlagSet
+	)
+
+	it.Before(func() {
+		flags = flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+	})
+
+	it("registers the flags", func() {
+		clientFlags := github.RegisterClientFlags(flags, github.ClientEnv{}, time.Minute)
+		Expect(clientFlags.RateLimitMaxWait).To(Equal(time.Minute))
+		Expect(clientFlags.HasCredentials()).To(BeFalse())
+
+		err := flags.Parse([]string{
+			"--token", "some-token",
+			"--rate-limit-max-wait", "5m",
+			"--app-id", "some-app-id",
+			"--app-installation-id", "some-installation-id",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(clientFlags.Token).To(Equal("some-token"))
+		Expect(clientFlags.RateLimitMaxWait).To(Equal(5 * time.Minute))
+		Expect(clientFlags.App).To(Equal(github.AppCredentials{
+			AppID:          "some-app-id",
+			InstallationID: "some-installation-id",
+		}))
+		Expect(clientFlags.HasCredentials()).To(BeTrue())
+	})
+
+	it("does not take the private key as a flag", func() {
+		github.RegisterClientFlags(flags, github.ClientEnv{}, time.Minute)
+
+		err := flags.Parse([]string{"--app-private-key", "some-key"})
+		Expect(err).To(MatchError(ContainSubstring("flag provided but not defined: -app-private-key")))
+	})
+
+	context("when credentials are given in the environment", func() {
+		it.Before(func() {
+			t.Setenv("SOME_TOKEN", "some-env-token")
+			t.Setenv("SOME_APP_ID", "some-env-app-id")
+			t.Setenv("SOME_INSTALLATION_ID", "some-env-installation-id")
+			t.Setenv("APP_PRIVATE_KEY", "some-private-key")
+		})
+
+		it("reads them, preferring flags", func() {
+			clientFlags := github.RegisterClientFlags(flags, github.ClientEnv{
+				Token:          "SOME_TOKEN",
+				AppID:          "SOME_APP_ID",
+				InstallationID: "SOME_INSTALLATION_ID",
+				PrivateKey:     "APP_PRIVATE_KEY",
+			}, time.Minute)
+
+			err := flags.Parse([]string{"--app-id", "some-app-id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(clientFlags.Token).To(Equal("some-env-token"))
+			Expect(clientFlags.App).To(Equal(github.AppCredentials{
+				AppID:          "some-app-id",
+				InstallationID: "some-env-installation-id",
+				PrivateKey:     "some-private-key",
+			}))
+		})
+
+		it("does not print them as defaults", func() {
+			github.RegisterClientFlags(flags, github.ClientEnv{Token: "SOME_TOKEN"}, time.Minute)
+
+			Expect(flags.Lookup("token").DefValue).To(BeEmpty())
+		})
+	})
+
+	context("NewClient", func() {
+		var (
+			api            *httptest.Server
+			authorizations []string
+			privatePEM     string
+		)
+
+		it.Before(func() {
+			authorizations = nil
+			api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				switch req.URL.Path {
+				case "/app/installations/some-installation-id/access_tokens":
+					w.WriteHeader(http.StatusCreated)
+					fmt.Fprintf(w, `{"token": "some-installation-token", "expires_at": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+
+				default:
+					authorizations = append(authorizations, req.Header.Get("Authorization"))
+					w.WriteHeader(http.StatusOK)
+					fmt.Fprintln(w, `{}`)
+				}
+			}))
+
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).NotTo(HaveOccurred())
+
+			privatePEM = string(pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(key),
+			}))
+		})
+
+		it.After(func() {
+			api.Close()
+		})
+
+		it("authenticates with the token", func() {
+			clientFlags := github.RegisterClientFlags(flags, github.ClientEnv{}, time.Minute)
+			Expect(flags.Parse([]string{"--token", "some-token"})).To(Succeed())
+
+			client, err := clientFlags.NewClient(api.URL)
+			Expect(err).NotTo(HaveOccurred())
+
+			req, err := client.NewRequest("GET", "/repos/some-org/some-repo", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = client.Do(req, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(authorizations).To(Equal([]string{"token some-token"}))
+		})
+
+		it("authenticates as the GitHub App installation", func() {
+			t.Setenv("INPUT_APP_PRIVATE_KEY", privatePEM)
+
+			clientFlags := github.RegisterClientFlags(flags, github.ClientEnv{PrivateKey: "INPUT_APP_PRIVATE_KEY"}, time.Minute)
+			Expect(flags.Parse([]string{
+				"--token", "some-token",
+				"--app-id", "some-app-id",
+				"--app-installation-id", "some-installation-id",
+			})).To(Succeed())
+
+			client, err := clientFlags.NewClient(api.URL)
+			Expect(err).NotTo(HaveOccurred())
+
+			req, err := client.NewRequest("GET", "/repos/some-org/some-repo", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = client.Do(req, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(authorizations).To(Equal([]string{"token some-installation-token"}))
+		})
+
+		context("when the private key is invalid", func() {
+			it("returns an error", func() {
+				t.Setenv("INPUT_APP_PRIVATE_KEY", "not-a-key")
+
+				clientFlags := github.RegisterClientFlags(flags, github.ClientEnv{PrivateKey: "INPUT_APP_PRIVATE_KEY"}, time.Minute)
+				Expect(flags.Parse([]string{"--app-id", "some-app-id", "--app-installation-id", "some-installation-id"})).To(Succeed())
+
+				_, err := clientFlags.NewClient(api.URL)
+				Expect(err).To(MatchError("failed to parse GitHub App private key: no PEM data found"))
+			})
+		})
+	})
+}
@@ -0,0 +1,14 @@
+package githubtest_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestGithubtest(t *testing.T) {
+	suite := spec.New("internal/github/githubtest", spec.Report(report.Terminal{}))
+	suite("Server", testServer)
+	suite.Run(t)
+}
@@ -0,0 +1,272 @@
+package githubtest
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+type Commit struct {
+	SHA     string
+	Message string
+}
+
+type Release struct {
+	ID              int
+	TagName         string
+	TargetCommitish string
+	Name            string
+	Body            string
+	Draft           bool
+	Prerelease      bool
+	CreatedAt       time.Time
+	Assets          []Asset
+}
+
+type Asset struct {
+	ID          int
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+// PullRequest is merged when MergedAt is set. Commits lists the SHAs of the
+// commits the pull request contributed to the default branch. Authors whose
+// login ends in "[bot]" are served as bots, and the author defaults to
+// "some-user".
+type PullRequest struct {
+	Number   int
+	Title    string
+	Author   string
+	Labels   []string
+	Commits  []string
+	MergedAt time.Time
+}
+
+type Artifact struct {
+	ID      int
+	RunID   string
+	Name    string
+	Content []byte
+}
+
+type Dispatch struct {
+	EventType     string          `json:"event_type"`
+	ClientPayload json.RawMessage `json:"client_payload"`
+}
+
+// Repo is an in-memory repository with a single linear history on its
+// default branch. The With methods build up the scenario a test runs against
+// and can be chained.
+type Repo struct {
+	server *Server
+
+	fullName      string
+	defaultBranch string
+	commits       []Commit
+	branches      map[string]string
+	tags          map[string]string
+	releases      []*Release
+	pulls         []*PullRequest
+	artifacts     []*Artifact
+	dispatches    []Dispatch
+	files         map[string][]byte
+}
+
+// WithCommit appends a commit to the default branch.
+func (r *Repo) WithCommit(sha, message string) *Repo {
+	r.server.m.Lock()
+	defer r.server.m.Unlock()
+
+	r.commits = append(r.commits, Commit{SHA: sha, Message: message})
+	return r
+}
+
+// WithBranch points a branch other than the default branch at the given ref.
+// The branch shares the history of the default branch up to that commit.
+func (r *Repo) WithBranch(name, ref string) *Repo {
+	r.server.m.Lock()
+	defer r.server.m.Unlock()
+
+	r.branches[name] = r.resolve(ref)
+	return r
+}
+
+// WithTag points a tag at the given ref, which may be a commit SHA, a branch
+// or another tag.
+func (r *Repo) WithTag(name, ref string) *Repo {
+	r.server.m.Lock()
+	defer r.server.m.Unlock()
+
+	r.tags[name] = r.resolve(ref)
+	return r
+}
+
+// WithRelease adds a release, tagging the target commitish when the tag does
+// not already exist. The target defaults to the default branch.
+func (r *Repo) WithRelease(release Release) *Repo {
+	r.server.m.Lock()
+	defer r.server.m.Unlock()
+
+	r.addRelease(release)
+	return r
+}
+
+// WithPullRequest adds a pull request. The number is assigned when it is
+// zero.
+func (r *Repo) WithPullRequest(pull PullRequest) *Repo {
+	r.server.m.Lock()
+	defer r.server.m.Unlock()
+
+	if pull.Number == 0 {
+		pull.Number = len(r.pulls) + 1
+	}
+
+	if pull.Author == "" {
+		pull.Author = "some-user"
+	}
+
+	r.pulls = append(r.pulls, &pull)
+	return r
+}
+
+// WithMergedPullRequest adds a commit to the default branch along with the
+// merged pull request that introduced it.
+func (r *Repo) WithMergedPullRequest(sha, message string, labels ...string) *Repo {
+	r.WithCommit(sha, message)
+	return r.WithPullRequest(PullRequest{
+		Title:    message,
+		Labels:   labels,
+		Commits:  []string{sha},
+		MergedAt: time.Now(),
+	})
+}
+
+// WithArtifact uploads a zip archive as an artifact of the given workflow
+// run.
+func (r *Repo) WithArtifact(runID, name string, content []byte) *Repo {
+	r.server.m.Lock()
+	defer r.server.m.Unlock()
+
+	r.artifacts = append(r.artifacts, &Artifact{
+		ID:      r.server.nextID(),
+		RunID:   runID,
+		Name:    name,
+		Content: content,
+	})
+	return r
+}
+
+// WithFile adds a file to the repository. Files are served at every ref.
+func (r *Repo) WithFile(path string, content []byte) *Repo {
+	r.server.m.Lock()
+	defer r.server.m.Unlock()
+
+	r.files[path] = content
+	return r
+}
+
+func (r *Repo) FullName() string {
+	return r.fullName
+}
+
+// Releases returns a copy of the releases, newest first.
+func (r *Repo) Releases() []Release {
+	r.server.m.Lock()
+	defer r.server.m.Unlock()
+
+	var releases []Release
+	for i := len(r.releases) - 1; i >= 0; i-- {
+		release := *r.releases[i]
+		release.Assets = slices.Clone(release.Assets)
+		releases = append(releases, release)
+	}
+
+	return releases
+}
+
+// Release returns the release with the given tag.
+func (r *Repo) Release(tag string) (Release, bool) {
+	for _, release := range r.Releases() {
+		if release.TagName == tag {
+			return release, true
+		}
+	}
+
+	return Release{}, false
+}
+
+func (r *Repo) Dispatches() []Dispatch {
+	r.server.m.Lock()
+	defer r.server.m.Unlock()
+
+	return slices.Clone(r.dispatches)
+}
+
+func (r *Repo) addRelease(release Release) *Release {
+	release.ID = r.server.nextID()
+	if release.TargetCommitish == "" {
+		release.TargetCommitish = r.defaultBranch
+	}
+
+	if release.CreatedAt.IsZero() {
+		release.CreatedAt = time.Now()
+	}
+
+	for i := range release.Assets {
+		release.Assets[i].ID = r.server.nextID()
+	}
+
+	if _, ok := r.tags[release.TagName]; !ok {
+		r.tags[release.TagName] = r.resolve(release.TargetCommitish)
+	}
+
+	r.releases = append(r.releases, &release)
+	return &release
+}
+
+func (r *Repo) findRelease(id int) (int, *Release) {
+	for i, release := range r.releases {
+		if release.ID == id {
+			return i, release
+		}
+	}
+
+	return -1, nil
+}
+
+// resolve returns the commit SHA that a branch, tag or SHA refers to, or an
+// empty string when the ref is unknown.
+func (r *Repo) resolve(ref string) string {
+	if ref == r.defaultBranch {
+		if len(r.commits) == 0 {
+			return ""
+		}
+
+		return r.commits[len(r.commits)-1].SHA
+	}
+
+	if sha, ok := r.branches[ref]; ok {
+		return sha
+	}
+
+	if sha, ok := r.tags[ref]; ok {
+		return sha
+	}
+
+	if r.commitIndex(ref) >= 0 {
+		return ref
+	}
+
+	return ""
+}
+
+func (r *Repo) commitIndex(sha string) int {
+	for i, commit := range r.commits {
+		if commit.SHA == sha {
+			return i
+		}
+	}
+
+	return -1
+}
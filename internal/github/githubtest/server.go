@@ -0,0 +1,763 @@
+// Package githubtest provides a stateful, in-memory fake of the parts of the
+// GitHub REST and GraphQL APIs used by the actions in this repository, so
+// that entrypoints can be tested end-to-end without reaching out to GitHub.
+package githubtest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPerPage = 30
+
+	// maxCompareCommits is the number of commits returned by an unpaginated
+	// compare request, see
+	// https://docs.github.com/en/rest/commits/commits#compare-two-commits.
+	maxCompareCommits = 250
+)
+
+// commitLookup matches a "alias: object(oid: $variable)" lookup in a GraphQL
+// query.
+var commitLookup = regexp.MustCompile(`(\w+)\s*:\s*object\s*\(\s*oid\s*:\s*\$(\w+)\s*\)`)
+
+// Request is a request received by the Server.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+type Server struct {
+	*httptest.Server
+
+	m         sync.Mutex
+	ids       int
+	token     string
+	repos     map[string]*Repo
+	requests  []Request
+	overrides map[string]http.HandlerFunc
+}
+
+func NewServer() *Server {
+	s := &Server{
+		repos:     map[string]*Repo{},
+		overrides: map[string]http.HandlerFunc{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/{owner}/{repo}", s.getRepo)
+	mux.HandleFunc("POST /repos/{owner}/{repo}/dispatches", s.createDispatch)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/compare/{basehead...}", s.compareCommits)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/commits/{sha}/pulls", s.listCommitPullRequests)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/contents/{path...}", s.getContents)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/releases", s.listReleases)
+	mux.HandleFunc("POST /repos/{owner}/{repo}/releases", s.createRelease)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/releases/{id}", s.getRelease)
+	mux.HandleFunc("PATCH /repos/{owner}/{repo}/releases/{id}", s.updateRelease)
+	mux.HandleFunc("DELETE /repos/{owner}/{repo}/releases/{id}", s.deleteRelease)
+	mux.HandleFunc("POST /repos/{owner}/{repo}/releases/{id}/assets", s.uploadReleaseAsset)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/actions/runs/{run}/artifacts", s.listRunArtifacts)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/actions/artifacts/{id}/zip", s.downloadArtifact)
+	mux.HandleFunc("POST /graphql", s.graphql)
+
+	s.Server = httptest.NewServer(s.record(mux))
+
+	return s
+}
+
+// RequireToken rejects any request that is not authenticated with the given
+// token.
+func (s *Server) RequireToken(token string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.token = token
+}
+
+// AddRepo creates an empty repository whose default branch is main, or
+// returns the existing repository with that name.
+func (s *Server) AddRepo(fullName string) *Repo {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if repo, ok := s.repos[fullName]; ok {
+		return repo
+	}
+
+	repo := &Repo{
+		server:        s,
+		fullName:      fullName,
+		defaultBranch: "main",
+		branches:      map[string]string{},
+		tags:          map[string]string{},
+		files:         map[string][]byte{},
+	}
+	s.repos[fullName] = repo
+
+	return repo
+}
+
+// Override replaces the response to every request with the given method and
+// path, for scenarios such as server errors that the fake does not model.
+func (s *Server) Override(method, path string, handler http.HandlerFunc) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.overrides[method+" "+path] = handler
+}
+
+// Requests returns every request received so far, in order.
+func (s *Server) Requests() []Request {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return slices.Clone(s.requests)
+}
+
+func (s *Server) nextID() int {
+	s.ids++
+	return s.ids
+}
+
+func (s *Server) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		s.m.Lock()
+		s.requests = append(s.requests, Request{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  req.URL.Query(),
+			Header: req.Header.Clone(),
+			Body:   body,
+		})
+		token := s.token
+		override := s.overrides[req.Method+" "+req.URL.Path]
+		s.m.Unlock()
+
+		if token != "" {
+			authorization := req.Header.Get("Authorization")
+			if authorization != "token "+token && authorization != "Bearer "+token {
+				writeError(w, http.StatusUnauthorized, "Bad credentials")
+				return
+			}
+		}
+
+		if override != nil {
+			override(w, req)
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// repo looks up the repository named in the request path and locks the
+// server. The caller must unlock the server when the repository is found.
+func (s *Server) repo(w http.ResponseWriter, req *http.Request) (*Repo, bool) {
+	s.m.Lock()
+
+	repo, ok := s.repos[req.PathValue("owner")+"/"+req.PathValue("repo")]
+	if !ok {
+		s.m.Unlock()
+		writeError(w, http.StatusNotFound, "Not Found")
+		return nil, false
+	}
+
+	return repo, true
+}
+
+func (s *Server) getRepo(w http.ResponseWriter, req *http.Request) {
+	repo, ok := s.repo(w, req)
+	if !ok {
+		return
+	}
+	defer s.m.Unlock()
+
+	name := repo.fullName[strings.Index(repo.fullName, "/")+1:]
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":           name,
+		"full_name":      repo.fullName,
+		"default_branch": repo.defaultBranch,
+	})
+}
+
+func (s *Server) createDispatch(w http.ResponseWriter, req *http.Request) {
+	repo, ok := s.repo(w, req)
+	if !ok {
+		return
+	}
+	defer s.m.Unlock()
+
+	var dispatch Dispatch
+	err := json.NewDecoder(req.Body).Decode(&dispatch)
+	if err != nil || dispatch.EventType == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Invalid request")
+		return
+	}
+
+	repo.dispatches = append(repo.dispatches, dispatch)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) compareCommits(w http.ResponseWriter, req *http.Request) {
+	repo, ok := s.repo(w, req)
+	if !ok {
+		return
+	}
+	defer s.m.Unlock()
+
+	base, head, found := strings.Cut(req.PathValue("basehead"), "...")
+	if !found {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	baseIndex := repo.commitIndex(repo.resolve(base))
+	headIndex := repo.commitIndex(repo.resolve(head))
+	if baseIndex < 0 || headIndex < 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No common ancestor between %s and %s.", base, head))
+		return
+	}
+
+	commits := []interface{}{}
+	for _, commit := range repo.commits[min(baseIndex+1, headIndex+1) : headIndex+1] {
+		commits = append(commits, commitJSON(commit))
+	}
+	total := len(commits)
+
+	if req.URL.Query().Has("page") || req.URL.Query().Has("per_page") {
+		commits = paginate(w, req, commits)
+	} else {
+		commits = commits[:min(len(commits), maxCompareCommits)]
+	}
+
+	status := "identical"
+	switch {
+	case headIndex > baseIndex:
+		status = "ahead"
+	case headIndex < baseIndex:
+		status = "behind"
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":        status,
+		"ahead_by":      max(headIndex-baseIndex, 0),
+		"behind_by":     max(baseIndex-headIndex, 0),
+		"total_commits": total,
+		"commits":       commits,
+	})
+}
+
+func (s *Server) listCommitPullRequests(w http.ResponseWriter, req *http.Request) {
+	repo, ok := s.repo(w, req)
+	if !ok {
+		return
+	}
+	defer s.m.Unlock()
+
+	sha := req.PathValue("sha")
+	if repo.commitIndex(sha) < 0 {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("No commit found for SHA: %s", sha))
+		return
+	}
+
+	pulls := []interface{}{}
+	for _, pull := range repo.pulls {
+		if slices.Contains(pull.Commits, sha) {
+			pulls = append(pulls, pullRequestJSON(pull))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, pulls)
+}
+
+func (s *Server) getContents(w http.ResponseWriter, req *http.Request) {
+	repo, ok := s.repo(w, req)
+	if !ok {
+		return
+	}
+	defer s.m.Unlock()
+
+	path := req.PathValue("path")
+	content, ok := repo.files[path]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"type":     "file",
+		"name":     path[strings.LastIndex(path, "/")+1:],
+		"path":     path,
+		"size":     len(content),
+		"encoding": "base64",
+		"content":  base64.StdEncoding.EncodeToString(content),
+	})
+}
+
+func (s *Server) listReleases(w http.ResponseWriter, req *http.Request) {
+	repo, ok := s.repo(w, req)
+	if !ok {
+		return
+	}
+	defer s.m.Unlock()
+
+	releases := []interface{}{}
+	for i := len(repo.releases) - 1; i >= 0; i-- {
+		releases = append(releases, s.releaseJSON(repo, repo.releases[i]))
+	}
+
+	writeJSON(w, http.StatusOK, paginate(w, req, releases))
+}
+
+func (s *Server) createRelease(w http.ResponseWriter, req *http.Request) {
+	repo, ok := s.repo(w, req)
+	if !ok {
+		return
+	}
+	defer s.m.Unlock()
+
+	var body struct {
+		TagName         string `json:"tag_name"`
+		TargetCommitish string `json:"target_commitish"`
+		Name            string `json:"name"`
+		Body            string `json:"body"`
+		Draft           bool   `json:"draft"`
+		Prerelease      bool   `json:"prerelease"`
+	}
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil || body.TagName == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+
+	for _, release := range repo.releases {
+		if release.TagName == body.TagName && !release.Draft {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+			return
+		}
+	}
+
+	release := repo.addRelease(Release{
+		TagName:         body.TagName,
+		TargetCommitish: body.TargetCommitish,
+		Name:            body.Name,
+		Body:            body.Body,
+		Draft:           body.Draft,
+		Prerelease:      body.Prerelease,
+	})
+
+	writeJSON(w, http.StatusCreated, s.releaseJSON(repo, release))
+}
+
+func (s *Server) getRelease(w http.ResponseWriter, req *http.Request) {
+	repo, ok := s.repo(w, req)
+	if !ok {
+		return
+	}
+	defer s.m.Unlock()
+
+	release, ok := findRelease(w, req, repo)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.releaseJSON(repo, release))
+}
+
+func (s *Server) updateRelease(w http.ResponseWriter, req *http.Request) {
+	repo, ok := s.repo(w, req)
+	if !ok {
+		return
+	}
+	defer s.m.Unlock()
+
+	release, ok := findRelease(w, req, repo)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		TagName    *string `json:"tag_name"`
+		Name       *string `json:"name"`
+		Body       *string `json:"body"`
+		Draft      *bool   `json:"draft"`
+		Prerelease *bool   `json:"prerelease"`
+	}
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+
+	if body.TagName != nil {
+		release.TagName = *body.TagName
+		if _, ok := repo.tags[release.TagName]; !ok {
+			repo.tags[release.TagName] = repo.resolve(release.TargetCommitish)
+		}
+	}
+
+	if body.Name != nil {
+		release.Name = *body.Name
+	}
+
+	if body.Body != nil {
+		release.Body = *body.Body
+	}
+
+	if body.Draft != nil {
+		release.Draft = *body.Draft
+	}
+
+	if body.Prerelease != nil {
+		release.Prerelease = *body.Prerelease
+	}
+
+	writeJSON(w, http.StatusOK, s.releaseJSON(repo, release))
+}
+
+func (s *Server) deleteRelease(w http.ResponseWriter, req *http.Request) {
+	repo, ok := s.repo(w, req)
+	if !ok {
+		return
+	}
+	defer s.m.Unlock()
+
+	release, ok := findRelease(w, req, repo)
+	if !ok {
+		return
+	}
+
+	i, _ := repo.findRelease(release.ID)
+	repo.releases = slices.Delete(repo.releases, i, i+1)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) uploadReleaseAsset(w http.ResponseWriter, req *http.Request) {
+	repo, ok := s.repo(w, req)
+	if !ok {
+		return
+	}
+	defer s.m.Unlock()
+
+	release, ok := findRelease(w, req, repo)
+	if !ok {
+		return
+	}
+
+	name := req.URL.Query().Get("name")
+	if name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+
+	for _, asset := range release.Assets {
+		if asset.Name == name {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+			return
+		}
+	}
+
+	content, err := io.ReadAll(req.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	asset := Asset{
+		ID:          s.nextID(),
+		Name:        name,
+		ContentType: req.Header.Get("Content-Type"),
+		Content:     content,
+	}
+	release.Assets = append(release.Assets, asset)
+
+	writeJSON(w, http.StatusCreated, assetJSON(asset))
+}
+
+func (s *Server) listRunArtifacts(w http.ResponseWriter, req *http.Request) {
+	repo, ok := s.repo(w, req)
+	if !ok {
+		return
+	}
+	defer s.m.Unlock()
+
+	artifacts := []interface{}{}
+	for _, artifact := range repo.artifacts {
+		if artifact.RunID != req.PathValue("run") {
+			continue
+		}
+
+		artifacts = append(artifacts, map[string]interface{}{
+			"id":                   artifact.ID,
+			"name":                 artifact.Name,
+			"size_in_bytes":        len(artifact.Content),
+			"archive_download_url": fmt.Sprintf("%s/repos/%s/actions/artifacts/%d/zip", s.URL, repo.fullName, artifact.ID),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"total_count": len(artifacts),
+		"artifacts":   artifacts,
+	})
+}
+
+func (s *Server) downloadArtifact(w http.ResponseWriter, req *http.Request) {
+	repo, ok := s.repo(w, req)
+	if !ok {
+		return
+	}
+	defer s.m.Unlock()
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err == nil {
+		for _, artifact := range repo.artifacts {
+			if artifact.ID == id {
+				w.Header().Set("Content-Type", "application/zip")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(artifact.Content)
+				return
+			}
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+// graphql answers queries that look up the pull requests associated with
+// commits, such as
+//
+//	repository(owner: $owner, name: $name) {
+//	  c0: object(oid: $c0) { ... on Commit { associatedPullRequests { ... } } }
+//	}
+//
+// Every commit lookup in the query is answered with all of the fields of its
+// associated pull requests. Other queries are rejected.
+func (s *Server) graphql(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+
+	if !strings.Contains(body.Query, "associatedPullRequests") {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"errors": []map[string]string{{"message": "githubtest: unsupported query"}},
+		})
+		return
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	owner, _ := body.Variables["owner"].(string)
+	name, _ := body.Variables["name"].(string)
+	repo, ok := s.repos[owner+"/"+name]
+	if !ok {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"repository": nil},
+			"errors": []map[string]string{{
+				"type":    "NOT_FOUND",
+				"message": fmt.Sprintf("Could not resolve to a Repository with the name '%s/%s'.", owner, name),
+			}},
+		})
+		return
+	}
+
+	repository := map[string]interface{}{}
+	for _, match := range commitLookup.FindAllStringSubmatch(body.Query, -1) {
+		sha, _ := body.Variables[match[2]].(string)
+		if repo.commitIndex(sha) < 0 {
+			repository[match[1]] = nil
+			continue
+		}
+
+		nodes := []interface{}{}
+		for _, pull := range repo.pulls {
+			if !slices.Contains(pull.Commits, sha) {
+				continue
+			}
+
+			labels := []interface{}{}
+			for _, label := range pull.Labels {
+				labels = append(labels, map[string]string{"name": label})
+			}
+
+			var mergedAt interface{}
+			if !pull.MergedAt.IsZero() {
+				mergedAt = pull.MergedAt.UTC().Format(time.RFC3339)
+			}
+
+			// GraphQL drops the "[bot]" suffix that REST adds to the logins of
+			// bots
+			author := map[string]string{"login": pull.Author, "__typename": "User"}
+			if login, isBot := strings.CutSuffix(pull.Author, "[bot]"); isBot {
+				author = map[string]string{"login": login, "__typename": "Bot"}
+			}
+
+			nodes = append(nodes, map[string]interface{}{
+				"number":   pull.Number,
+				"title":    pull.Title,
+				"author":   author,
+				"mergedAt": mergedAt,
+				"labels":   map[string]interface{}{"nodes": labels},
+			})
+		}
+
+		repository[match[1]] = map[string]interface{}{
+			"associatedPullRequests": map[string]interface{}{"nodes": nodes},
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{"repository": repository},
+	})
+}
+
+func (s *Server) releaseJSON(repo *Repo, release *Release) map[string]interface{} {
+	assets := []interface{}{}
+	for _, asset := range release.Assets {
+		assets = append(assets, assetJSON(asset))
+	}
+
+	return map[string]interface{}{
+		"id":               release.ID,
+		"tag_name":         release.TagName,
+		"target_commitish": release.TargetCommitish,
+		"name":             release.Name,
+		"body":             release.Body,
+		"draft":            release.Draft,
+		"prerelease":       release.Prerelease,
+		"created_at":       release.CreatedAt.UTC().Format(time.RFC3339),
+		"upload_url":       fmt.Sprintf("%s/repos/%s/releases/%d/assets{?name,label}", s.URL, repo.fullName, release.ID),
+		"assets":           assets,
+	}
+}
+
+func findRelease(w http.ResponseWriter, req *http.Request, repo *Repo) (*Release, bool) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err == nil {
+		if _, release := repo.findRelease(id); release != nil {
+			return release, true
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "Not Found")
+	return nil, false
+}
+
+func commitJSON(commit Commit) map[string]interface{} {
+	return map[string]interface{}{
+		"sha": commit.SHA,
+		"commit": map[string]interface{}{
+			"message": commit.Message,
+		},
+	}
+}
+
+func pullRequestJSON(pull *PullRequest) map[string]interface{} {
+	labels := []interface{}{}
+	for _, label := range pull.Labels {
+		labels = append(labels, map[string]string{"name": label})
+	}
+
+	var mergedAt interface{}
+	state := "open"
+	if !pull.MergedAt.IsZero() {
+		mergedAt = pull.MergedAt.UTC().Format(time.RFC3339)
+		state = "closed"
+	}
+
+	userType := "User"
+	if strings.HasSuffix(pull.Author, "[bot]") {
+		userType = "Bot"
+	}
+
+	return map[string]interface{}{
+		"number":    pull.Number,
+		"title":     pull.Title,
+		"user":      map[string]string{"login": pull.Author, "type": userType},
+		"state":     state,
+		"merged_at": mergedAt,
+		"labels":    labels,
+	}
+}
+
+func assetJSON(asset Asset) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           asset.ID,
+		"name":         asset.Name,
+		"content_type": asset.ContentType,
+		"size":         len(asset.Content),
+	}
+}
+
+// paginate returns the page of items requested by the page and per_page
+// query parameters and sets a Link header pointing at the next page, when
+// there is one.
+func paginate(w http.ResponseWriter, req *http.Request, items []interface{}) []interface{} {
+	perPage, err := strconv.Atoi(req.URL.Query().Get("per_page"))
+	if err != nil || perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	perPage = min(perPage, 100)
+
+	page, err := strconv.Atoi(req.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+
+	if end < len(items) {
+		next := *req.URL
+		query := next.Query()
+		query.Set("page", strconv.Itoa(page+1))
+		query.Set("per_page", strconv.Itoa(perPage))
+		next.RawQuery = query.Encode()
+
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next"`, req.Host, next.RequestURI()))
+	}
+
+	return items[start:end]
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{
+		"message":           message,
+		"documentation_url": "https://docs.github.com/rest",
+	})
+}
@@ -0,0 +1,371 @@
+package githubtest_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/github-config/internal/github"
+	"github.com/paketo-buildpacks/github-config/internal/github/githubtest"
+)
+
+func testServer(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		server *githubtest.Server
+		repo   *githubtest.Repo
+		client *github.Client
+	)
+
+	it.Before(func() {
+		server = githubtest.NewServer()
+		repo = server.AddRepo("some-org/some-repo").
+			WithCommit("sha-1", "Initial commit").
+			WithRelease(githubtest.Release{TagName: "v1.0.0"}).
+			WithMergedPullRequest("sha-2", "Adds a feature", "semver:minor").
+			WithMergedPullRequest("sha-3", "Fixes a bug", "semver:patch")
+
+		client = github.NewClient(server.URL, github.WithToken("some-token"))
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	do := func(method, path, body string, v interface{}) (*github.Response, error) {
+		req, err := client.NewRequest(method, path, strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+
+		return client.Do(req, v)
+	}
+
+	it("serves the repository", func() {
+		var body struct {
+			FullName      string `json:"full_name"`
+			DefaultBranch string `json:"default_branch"`
+		}
+		_, err := do("GET", "/repos/some-org/some-repo", "", &body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(body.FullName).To(Equal("some-org/some-repo"))
+		Expect(body.DefaultBranch).To(Equal("main"))
+	})
+
+	it("returns 404 for unknown repositories", func() {
+		_, err := do("GET", "/repos/some-org/missing-repo", "", nil)
+
+		var apiErr *github.Error
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.StatusCode).To(Equal(http.StatusNotFound))
+		Expect(apiErr.Message).To(Equal("Not Found"))
+	})
+
+	it("compares commits between a tag and a branch", func() {
+		var body struct {
+			Status  string `json:"status"`
+			Commits []struct {
+				SHA    string `json:"sha"`
+				Commit struct {
+					Message string `json:"message"`
+				} `json:"commit"`
+			} `json:"commits"`
+		}
+		_, err := do("GET", "/repos/some-org/some-repo/compare/v1.0.0...main", "", &body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(body.Status).To(Equal("ahead"))
+		Expect(body.Commits).To(HaveLen(2))
+		Expect(body.Commits[0].SHA).To(Equal("sha-2"))
+		Expect(body.Commits[1].SHA).To(Equal("sha-3"))
+		Expect(body.Commits[1].Commit.Message).To(Equal("Fixes a bug"))
+	})
+
+	it("compares commits with other branches", func() {
+		repo.WithBranch("v1.x", "sha-2")
+
+		var body struct {
+			Commits []struct {
+				SHA string `json:"sha"`
+			} `json:"commits"`
+		}
+		_, err := do("GET", "/repos/some-org/some-repo/compare/v1.0.0...v1.x", "", &body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(body.Commits).To(HaveLen(1))
+		Expect(body.Commits[0].SHA).To(Equal("sha-2"))
+	})
+
+	it("paginates compared commits when asked to", func() {
+		for i := 4; i <= 6; i++ {
+			repo.WithCommit(fmt.Sprintf("sha-%d", i), "Another commit")
+		}
+
+		var body struct {
+			TotalCommits int `json:"total_commits"`
+			Commits      []struct {
+				SHA string `json:"sha"`
+			} `json:"commits"`
+		}
+		resp, err := do("GET", "/repos/some-org/some-repo/compare/v1.0.0...main?per_page=2", "", &body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(body.TotalCommits).To(Equal(5))
+		Expect(body.Commits).To(HaveLen(2))
+		Expect(resp.NextURL).To(ContainSubstring("page=2"))
+
+		_, err = do("GET", resp.NextURL, "", &body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(body.Commits[0].SHA).To(Equal("sha-4"))
+	})
+
+	it("looks up the pull requests associated with commits over GraphQL", func() {
+		var data struct {
+			Repository map[string]*struct {
+				AssociatedPullRequests struct {
+					Nodes []struct {
+						Number   int     `json:"number"`
+						MergedAt *string `json:"mergedAt"`
+						Labels   struct {
+							Nodes []struct {
+								Name string `json:"name"`
+							} `json:"nodes"`
+						} `json:"labels"`
+					} `json:"nodes"`
+				} `json:"associatedPullRequests"`
+			} `json:"repository"`
+		}
+		err := client.GraphQL(`query($owner: String!, $name: String!, $c0: GitObjectID!, $c1: GitObjectID!) {
+			repository(owner: $owner, name: $name) {
+				c0: object(oid: $c0) { ... on Commit { associatedPullRequests(first: 10) { nodes { number mergedAt labels(first: 100) { nodes { name } } } } } }
+				c1: object(oid: $c1) { ... on Commit { associatedPullRequests(first: 10) { nodes { number mergedAt labels(first: 100) { nodes { name } } } } } }
+			}
+		}`, map[string]interface{}{"owner": "some-org", "name": "some-repo", "c0": "sha-1", "c1": "sha-3"}, &data)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(data.Repository["c0"].AssociatedPullRequests.Nodes).To(BeEmpty())
+		Expect(data.Repository["c1"].AssociatedPullRequests.Nodes).To(HaveLen(1))
+		Expect(data.Repository["c1"].AssociatedPullRequests.Nodes[0].Number).To(Equal(2))
+		Expect(data.Repository["c1"].AssociatedPullRequests.Nodes[0].MergedAt).NotTo(BeNil())
+		Expect(data.Repository["c1"].AssociatedPullRequests.Nodes[0].Labels.Nodes[0].Name).To(Equal("semver:patch"))
+	})
+
+	it("lists the pull requests for a commit", func() {
+		var pulls []struct {
+			Number   int     `json:"number"`
+			MergedAt *string `json:"merged_at"`
+			Labels   []struct {
+				Name string `json:"name"`
+			} `json:"labels"`
+		}
+		_, err := do("GET", "/repos/some-org/some-repo/commits/sha-2/pulls", "", &pulls)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pulls).To(HaveLen(1))
+		Expect(pulls[0].Number).To(Equal(1))
+		Expect(pulls[0].MergedAt).NotTo(BeNil())
+		Expect(pulls[0].Labels[0].Name).To(Equal("semver:minor"))
+	})
+
+	it("serves bots as the authors of pull requests", func() {
+		repo.WithCommit("sha-4", "Bumps a dependency").
+			WithPullRequest(githubtest.PullRequest{Author: "dependabot[bot]", Commits: []string{"sha-4"}, MergedAt: time.Now()})
+
+		var pulls []struct {
+			User struct {
+				Login string `json:"login"`
+				Type  string `json:"type"`
+			} `json:"user"`
+		}
+		_, err := do("GET", "/repos/some-org/some-repo/commits/sha-4/pulls", "", &pulls)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pulls).To(HaveLen(1))
+		Expect(pulls[0].User.Login).To(Equal("dependabot[bot]"))
+		Expect(pulls[0].User.Type).To(Equal("Bot"))
+
+		var data struct {
+			Repository map[string]*struct {
+				AssociatedPullRequests struct {
+					Nodes []struct {
+						Author struct {
+							Login    string `json:"login"`
+							Typename string `json:"__typename"`
+						} `json:"author"`
+					} `json:"nodes"`
+				} `json:"associatedPullRequests"`
+			} `json:"repository"`
+		}
+		err = client.GraphQL(`query($owner: String!, $name: String!, $c0: GitObjectID!, $c1: GitObjectID!) {
+			repository(owner: $owner, name: $name) {
+				c0: object(oid: $c0) { ... on Commit { associatedPullRequests(first: 10) { nodes { author { login __typename } } } } }
+				c1: object(oid: $c1) { ... on Commit { associatedPullRequests(first: 10) { nodes { author { login __typename } } } } }
+			}
+		}`, map[string]interface{}{"owner": "some-org", "name": "some-repo", "c0": "sha-2", "c1": "sha-4"}, &data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data.Repository["c0"].AssociatedPullRequests.Nodes[0].Author.Login).To(Equal("some-user"))
+		Expect(data.Repository["c0"].AssociatedPullRequests.Nodes[0].Author.Typename).To(Equal("User"))
+		Expect(data.Repository["c1"].AssociatedPullRequests.Nodes[0].Author.Login).To(Equal("dependabot"))
+		Expect(data.Repository["c1"].AssociatedPullRequests.Nodes[0].Author.Typename).To(Equal("Bot"))
+	})
+
+	it("serves the contents of files", func() {
+		repo.WithFile(".github/some-file.yml", []byte("some-content"))
+
+		var file struct {
+			Path     string `json:"path"`
+			Encoding string `json:"encoding"`
+			Content  string `json:"content"`
+		}
+		_, err := do("GET", "/repos/some-org/some-repo/contents/.github/some-file.yml?ref=main", "", &file)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file.Path).To(Equal(".github/some-file.yml"))
+		Expect(file.Encoding).To(Equal("base64"))
+		Expect(file.Content).To(Equal(base64.StdEncoding.EncodeToString([]byte("some-content"))))
+
+		_, err = do("GET", "/repos/some-org/some-repo/contents/.github/missing-file.yml", "", nil)
+		var apiErr *github.Error
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.StatusCode).To(Equal(http.StatusNotFound))
+	})
+
+	it("creates, uploads to, publishes and deletes releases", func() {
+		var release struct {
+			ID        int    `json:"id"`
+			UploadURL string `json:"upload_url"`
+		}
+		_, err := do("POST", "/repos/some-org/some-repo/releases", `{"tag_name": "v1.1.0", "name": "v1.1.0", "draft": true}`, &release)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(release.UploadURL).To(Equal(fmt.Sprintf("%s/repos/some-org/some-repo/releases/%d/assets{?name,label}", server.URL, release.ID)))
+
+		req, err := client.NewRequest("POST", fmt.Sprintf("%s/repos/some-org/some-repo/releases/%d/assets?name=some-asset.tgz", server.URL, release.ID), strings.NewReader("some-content"))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/gzip")
+		_, err = client.Do(req, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		draft, ok := repo.Release("v1.1.0")
+		Expect(ok).To(BeTrue())
+		Expect(draft.Draft).To(BeTrue())
+		Expect(draft.Assets).To(HaveLen(1))
+		Expect(draft.Assets[0].Name).To(Equal("some-asset.tgz"))
+		Expect(draft.Assets[0].ContentType).To(Equal("application/gzip"))
+		Expect(string(draft.Assets[0].Content)).To(Equal("some-content"))
+
+		_, err = do("PATCH", fmt.Sprintf("/repos/some-org/some-repo/releases/%d", release.ID), `{"draft": false}`, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		releases := repo.Releases()
+		Expect(releases).To(HaveLen(2))
+		Expect(releases[0].TagName).To(Equal("v1.1.0"))
+		Expect(releases[0].Draft).To(BeFalse())
+
+		var compare struct {
+			Status string `json:"status"`
+		}
+		_, err = do("GET", "/repos/some-org/some-repo/compare/v1.1.0...main", "", &compare)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(compare.Status).To(Equal("identical"))
+
+		_, err = do("DELETE", fmt.Sprintf("/repos/some-org/some-repo/releases/%d", release.ID), "", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, ok = repo.Release("v1.1.0")
+		Expect(ok).To(BeFalse())
+	})
+
+	it("paginates releases", func() {
+		for i := 1; i <= 4; i++ {
+			repo.WithRelease(githubtest.Release{TagName: fmt.Sprintf("v1.0.%d", i)})
+		}
+
+		type release struct {
+			TagName string `json:"tag_name"`
+		}
+		releases, err := github.GetAll[release](client, "/repos/some-org/some-repo/releases?per_page=2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(releases).To(Equal([]release{
+			{TagName: "v1.0.4"},
+			{TagName: "v1.0.3"},
+			{TagName: "v1.0.2"},
+			{TagName: "v1.0.1"},
+			{TagName: "v1.0.0"},
+		}))
+		Expect(server.Requests()).To(HaveLen(3))
+	})
+
+	it("records dispatches", func() {
+		_, err := do("POST", "/repos/some-org/some-repo/dispatches", `{"event_type": "some-event", "client_payload": {"key": "value"}}`, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		dispatches := repo.Dispatches()
+		Expect(dispatches).To(HaveLen(1))
+		Expect(dispatches[0].EventType).To(Equal("some-event"))
+		Expect(string(dispatches[0].ClientPayload)).To(MatchJSON(`{"key": "value"}`))
+	})
+
+	it("serves workflow run artifacts", func() {
+		repo.WithArtifact("12345", "some-artifact", []byte("some-zip"))
+
+		var body struct {
+			Artifacts []struct {
+				Name               string `json:"name"`
+				ArchiveDownloadURL string `json:"archive_download_url"`
+				SizeInBytes        int    `json:"size_in_bytes"`
+			} `json:"artifacts"`
+		}
+		_, err := do("GET", "/repos/some-org/some-repo/actions/runs/12345/artifacts", "", &body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(body.Artifacts).To(HaveLen(1))
+		Expect(body.Artifacts[0].SizeInBytes).To(Equal(8))
+
+		content := &strings.Builder{}
+		_, err = do("GET", body.Artifacts[0].ArchiveDownloadURL, "", content)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content.String()).To(Equal("some-zip"))
+	})
+
+	it("records every request", func() {
+		_, err := do("POST", "/repos/some-org/some-repo/dispatches", `{"event_type": "some-event"}`, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		requests := server.Requests()
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Method).To(Equal("POST"))
+		Expect(requests[0].Path).To(Equal("/repos/some-org/some-repo/dispatches"))
+		Expect(requests[0].Header.Get("Authorization")).To(Equal("token some-token"))
+		Expect(string(requests[0].Body)).To(MatchJSON(`{"event_type": "some-event"}`))
+	})
+
+	context("when a token is required", func() {
+		it.Before(func() {
+			server.RequireToken("other-token")
+		})
+
+		it("rejects requests with a different token", func() {
+			_, err := do("GET", "/repos/some-org/some-repo", "", nil)
+
+			var apiErr *github.Error
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(apiErr.Message).To(Equal("Bad credentials"))
+		})
+	})
+
+	context("when a response is overridden", func() {
+		it.Before(func() {
+			server.Override("GET", "/repos/some-org/some-repo/releases", func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			})
+		})
+
+		it("serves the override", func() {
+			_, err := do("GET", "/repos/some-org/some-repo/releases", "", nil)
+
+			var apiErr *github.Error
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.StatusCode).To(Equal(http.StatusInternalServerError))
+		})
+	})
+}
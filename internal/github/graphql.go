@@ -0,0 +1,87 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// GraphQLError describes the errors returned in a GraphQL response, which is
+// sent with a 200 status even when the query fails.
+type GraphQLError struct {
+	Errors []struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+
+		// Path holds the field names and list indices, as strings and
+		// numbers, that lead to the field the error is about.
+		Path []interface{} `json:"path"`
+	}
+}
+
+func (e *GraphQLError) Error() string {
+	var messages []string
+	for _, err := range e.Errors {
+		messages = append(messages, err.Message)
+	}
+
+	return fmt.Sprintf("graphql: %s", strings.Join(messages, "; "))
+}
+
+// GraphQL sends the query to the GraphQL API and decodes the data of the
+// response into v, as described in
+// https://docs.github.com/en/graphql/guides/forming-calls-with-graphql.
+// Errors in the response are returned as *GraphQLError.
+func (c *Client) GraphQL(query string, variables map[string]interface{}, v interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := c.NewRequest("POST", c.graphQLURL(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors json.RawMessage `json:"errors"`
+	}
+	_, err = c.Do(req, &response)
+	if err != nil {
+		return err
+	}
+
+	if len(response.Errors) > 0 && string(response.Errors) != "null" {
+		var graphQLErr GraphQLError
+		err = json.Unmarshal(response.Errors, &graphQLErr.Errors)
+		if err != nil {
+			return fmt.Errorf("failed to parse graphql errors: %w", err)
+		}
+
+		return &graphQLErr
+	}
+
+	if v == nil || len(response.Data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(response.Data, v)
+}
+
+// graphQLURL returns the URL of the GraphQL API of the endpoint. GitHub
+// Enterprise Server serves its REST API under /api/v3 and its GraphQL API at
+// /api/graphql, see
+// https://docs.github.com/en/enterprise-server@latest/graphql/guides/forming-calls-with-graphql.
+func (c *Client) graphQLURL() string {
+	if base, ok := strings.CutSuffix(c.endpoint, "/api/v3"); ok {
+		return base + "/api/graphql"
+	}
+
+	return c.endpoint + "/graphql"
+}
@@ -0,0 +1,131 @@
+package github_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/github-config/internal/github"
+)
+
+func testGraphQL(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		api    *httptest.Server
+		body   map[string]interface{}
+		client *github.Client
+	)
+
+	it.Before(func() {
+		api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodPost || req.URL.Path != "/graphql" {
+				t.Fatalf("unknown request: %s %s", req.Method, req.URL.Path)
+			}
+
+			if req.Header.Get("Authorization") != "token some-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			body = nil
+			Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+
+			switch body["variables"].(map[string]interface{})["name"] {
+			case "some-repo":
+				fmt.Fprintln(w, `{ "data": { "repository": { "nameWithOwner": "some-org/some-repo" } } }`)
+
+			case "missing-repo":
+				fmt.Fprintln(w, `{ "data": { "repository": null }, "errors": [{ "type": "NOT_FOUND", "message": "Could not resolve to a Repository with the name 'some-org/missing-repo'." }] }`)
+
+			case "missing-commit":
+				fmt.Fprintln(w, `{ "data": { "repository": { "c0": null } }, "errors": [{ "type": "NOT_FOUND", "path": ["repository", "c0", "associatedPullRequests", "nodes", 0], "message": "Could not resolve to a node." }] }`)
+
+			default:
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		}))
+
+		client = github.NewClient(api.URL, github.WithToken("some-token"))
+	})
+
+	it.After(func() {
+		api.Close()
+	})
+
+	it("sends the query and variables and decodes the data", func() {
+		var data struct {
+			Repository struct {
+				NameWithOwner string `json:"nameWithOwner"`
+			} `json:"repository"`
+		}
+		err := client.GraphQL(`query($name: String!) { repository(owner: "some-org", name: $name) { nameWithOwner } }`, map[string]interface{}{"name": "some-repo"}, &data)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(body["query"]).To(ContainSubstring("repository("))
+		Expect(data.Repository.NameWithOwner).To(Equal("some-org/some-repo"))
+	})
+
+	context("when the endpoint is a GitHub Enterprise Server REST API", func() {
+		it("sends the query to its GraphQL API", func() {
+			var path string
+			api.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				path = req.URL.Path
+				fmt.Fprintln(w, `{ "data": { "repository": { "nameWithOwner": "some-org/some-repo" } } }`)
+			})
+
+			client = github.NewClient(api.URL+"/api/v3", github.WithToken("some-token"))
+
+			var data struct {
+				Repository struct {
+					NameWithOwner string `json:"nameWithOwner"`
+				} `json:"repository"`
+			}
+			err := client.GraphQL(`query($name: String!) { repository(owner: "some-org", name: $name) { nameWithOwner } }`, map[string]interface{}{"name": "some-repo"}, &data)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(path).To(Equal("/api/graphql"))
+			Expect(data.Repository.NameWithOwner).To(Equal("some-org/some-repo"))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the response has errors", func() {
+			it("returns a GraphQLError", func() {
+				err := client.GraphQL(`query($name: String!) { repository(owner: "some-org", name: $name) { nameWithOwner } }`, map[string]interface{}{"name": "missing-repo"}, nil)
+				Expect(err).To(MatchError("graphql: Could not resolve to a Repository with the name 'some-org/missing-repo'."))
+
+				var graphQLErr *github.GraphQLError
+				Expect(errors.As(err, &graphQLErr)).To(BeTrue())
+				Expect(graphQLErr.Errors[0].Type).To(Equal("NOT_FOUND"))
+			})
+		})
+
+		context("when the path of an error holds list indices", func() {
+			it("returns a GraphQLError", func() {
+				err := client.GraphQL(`query($name: String!) { repository(owner: "some-org", name: $name) { nameWithOwner } }`, map[string]interface{}{"name": "missing-commit"}, nil)
+				Expect(err).To(MatchError("graphql: Could not resolve to a node."))
+
+				var graphQLErr *github.GraphQLError
+				Expect(errors.As(err, &graphQLErr)).To(BeTrue())
+				Expect(graphQLErr.Errors[0].Path).To(Equal([]interface{}{"repository", "c0", "associatedPullRequests", "nodes", float64(0)}))
+			})
+		})
+
+		context("when the response status is not successful", func() {
+			it("returns an Error", func() {
+				err := client.GraphQL(`query($name: String!) { repository(owner: "some-org", name: $name) { nameWithOwner } }`, map[string]interface{}{"name": "broken-repo"}, nil)
+
+				var apiErr *github.Error
+				Expect(errors.As(err, &apiErr)).To(BeTrue())
+				Expect(apiErr.StatusCode).To(Equal(http.StatusBadGateway))
+			})
+		})
+	})
+}
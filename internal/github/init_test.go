@@ -0,0 +1,19 @@
+package github_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestGithub(t *testing.T) {
+	suite := spec.New("internal/github", spec.Report(report.Terminal{}))
+	suite("App", testApp)
+	suite("Client", testClient)
+	suite("Flags", testFlags)
+	suite("GraphQL", testGraphQL)
+	suite("Pagination", testPagination)
+	suite("RateLimit", testRateLimit)
+	suite.Run(t)
+}
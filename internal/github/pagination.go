@@ -0,0 +1,50 @@
+package github
+
+import "strings"
+
+// GetAll requests every page of a list endpoint, following the "next" links
+// returned in the Link header, and returns the combined results.
+func GetAll[T any](c *Client, path string) ([]T, error) {
+	var all []T
+	for path != "" {
+		req, err := c.NewRequest("GET", path, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page []T
+		resp, err := c.Do(req, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, page...)
+		path = resp.NextURL
+	}
+
+	return all, nil
+}
+
+// parseNextLink returns the URL with rel="next" from a Link header, as
+// described in https://docs.github.com/en/rest/using-the-rest-api/using-pagination-in-the-rest-api.
+func parseNextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		segments := strings.Split(strings.TrimSpace(link), ";")
+		if len(segments) < 2 {
+			continue
+		}
+
+		uri := strings.TrimSpace(segments[0])
+		if !strings.HasPrefix(uri, "<") || !strings.HasSuffix(uri, ">") {
+			continue
+		}
+
+		for _, param := range segments[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(uri, "<>")
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,92 @@
+package github_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/github-config/internal/github"
+)
+
+func testPagination(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		api      *httptest.Server
+		requests []*http.Request
+		client   *github.Client
+	)
+
+	it.Before(func() {
+		requests = nil
+		api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			requests = append(requests, req)
+
+			switch req.URL.Path {
+			case "/repos/some-org/some-repo/releases":
+				switch req.URL.Query().Get("page") {
+				case "":
+					w.Header().Set("Link", fmt.Sprintf(`<http://%s/repos/some-org/some-repo/releases?page=2>; rel="next", <http://%s/repos/some-org/some-repo/releases?page=3>; rel="last"`, req.Host, req.Host))
+					fmt.Fprintln(w, `[{ "tag_name": "v1.2.3" }]`)
+				case "2":
+					w.Header().Set("Link", fmt.Sprintf(`<http://%s/repos/some-org/some-repo/releases?page=1>; rel="prev", <http://%s/repos/some-org/some-repo/releases?page=3>; rel="next"`, req.Host, req.Host))
+					fmt.Fprintln(w, `[{ "tag_name": "v1.2.2" }]`)
+				case "3":
+					fmt.Fprintln(w, `[{ "tag_name": "v1.2.1" }]`)
+				}
+
+			case "/repos/some-org/broken-repo/releases":
+				if req.URL.Query().Get("page") == "" {
+					w.Header().Set("Link", fmt.Sprintf(`<http://%s/repos/some-org/broken-repo/releases?page=2>; rel="next"`, req.Host))
+					fmt.Fprintln(w, `[{ "tag_name": "v1.2.3" }]`)
+					return
+				}
+				w.WriteHeader(http.StatusBadGateway)
+
+			default:
+				t.Fatalf("unknown path: %s", req.URL.Path)
+			}
+		}))
+
+		client = github.NewClient(api.URL, github.WithToken("some-token"))
+	})
+
+	it.After(func() {
+		api.Close()
+	})
+
+	context("GetAll", func() {
+		type release struct {
+			TagName string `json:"tag_name"`
+		}
+
+		it("follows the next links and returns every page", func() {
+			releases, err := github.GetAll[release](client, "/repos/some-org/some-repo/releases")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(releases).To(Equal([]release{
+				{TagName: "v1.2.3"},
+				{TagName: "v1.2.2"},
+				{TagName: "v1.2.1"},
+			}))
+
+			Expect(requests).To(HaveLen(3))
+			for _, req := range requests {
+				Expect(req.Header.Get("Authorization")).To(Equal("token some-token"))
+			}
+		})
+
+		context("failure cases", func() {
+			context("when a later page fails", func() {
+				it("returns an error", func() {
+					_, err := github.GetAll[release](client, "/repos/some-org/broken-repo/releases")
+					Expect(err).To(MatchError(ContainSubstring("502 Bad Gateway")))
+				})
+			})
+		})
+	})
+}
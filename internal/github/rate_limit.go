@@ -0,0 +1,159 @@
+package github
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitTransport is an http.RoundTripper that waits out primary and
+// secondary GitHub rate limits and then resumes. Requests are retried after a
+// rate-limited response for as long as the total time spent waiting stays
+// within MaxWait, after which the rate-limited response is returned as-is.
+type RateLimitTransport struct {
+	Transport http.RoundTripper
+	MaxWait   time.Duration
+	Output    io.Writer
+
+	// SecondaryWait is how long to back off from a secondary rate limit when
+	// the response does not include a Retry-After header. GitHub recommends
+	// at least a minute, see
+	// https://docs.github.com/en/rest/using-the-rest-api/rate-limits-for-the-rest-api#handle-rate-limit-errors-appropriately.
+	SecondaryWait time.Duration
+
+	m       sync.Mutex
+	waited  time.Duration
+	resetAt time.Time
+}
+
+func NewRateLimitTransport(transport http.RoundTripper, maxWait time.Duration, output io.Writer) *RateLimitTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &RateLimitTransport{
+		Transport:     transport,
+		MaxWait:       maxWait,
+		Output:        output,
+		SecondaryWait: time.Minute,
+	}
+}
+
+// Waited returns the total time spent waiting on rate limits.
+func (t *RateLimitTransport) Waited() time.Duration {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	return t.waited
+}
+
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for {
+		t.m.Lock()
+		wait := time.Until(t.resetAt)
+		t.m.Unlock()
+
+		if wait > 0 && t.reserve(wait) {
+			fmt.Fprintf(t.Output, "Primary rate limit exhausted, waiting %s before %s %s\n", wait.Round(time.Second), req.Method, req.URL)
+			time.Sleep(wait)
+		}
+
+		resp, err := t.Transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		wait, reason := t.rateLimitWait(resp)
+		if reason == "" {
+			return resp, nil
+		}
+
+		// Requests whose body cannot be replayed are not retried
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return resp, nil
+		}
+
+		if !t.reserve(wait) {
+			fmt.Fprintf(t.Output, "Hit %s on %s %s, waiting %s would exceed the maximum wait of %s\n", reason, req.Method, req.URL, wait.Round(time.Second), t.MaxWait)
+			return resp, nil
+		}
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		fmt.Fprintf(t.Output, "Hit %s on %s %s, waiting %s\n", reason, req.Method, req.URL, wait.Round(time.Second))
+		time.Sleep(wait)
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// reserve records the given wait against MaxWait, returning false when it
+// would exceed the ceiling.
+func (t *RateLimitTransport) reserve(wait time.Duration) bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if t.waited+wait > t.MaxWait {
+		return false
+	}
+
+	t.waited += wait
+	return true
+}
+
+// rateLimitWait inspects the response for rate-limit headers and returns how
+// long to wait before retrying, along with a description of the limit that
+// was hit. An empty description means the request was not rate limited.
+func (t *RateLimitTransport) rateLimitWait(resp *http.Response) (time.Duration, string) {
+	var reset time.Time
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		seconds, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err == nil {
+			reset = time.Unix(seconds, 0)
+
+			t.m.Lock()
+			t.resetAt = reset
+			t.m.Unlock()
+		}
+	}
+
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, ""
+	}
+
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		seconds, err := strconv.Atoi(retryAfter)
+		if err == nil {
+			return time.Duration(seconds) * time.Second, "secondary rate limit"
+		}
+	}
+
+	if !reset.IsZero() {
+		// The reset time has a granularity of one second, so always wait at
+		// least that long
+		return max(time.Until(reset), time.Second), "primary rate limit"
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err == nil && strings.Contains(strings.ToLower(string(body)), "secondary rate limit") {
+		return t.SecondaryWait, "secondary rate limit"
+	}
+
+	return 0, ""
+}
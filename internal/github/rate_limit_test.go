@@ -0,0 +1,174 @@
+package github_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega/gbytes"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/github-config/internal/github"
+)
+
+func testRateLimit(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		api       *httptest.Server
+		requests  []*http.Request
+		bodies    []string
+		limited   map[string]int
+		buffer    *gbytes.Buffer
+		transport *github.RateLimitTransport
+		client    *http.Client
+	)
+
+	it.Before(func() {
+		requests = nil
+		bodies = nil
+		limited = map[string]int{
+			"/secondary":         1,
+			"/secondary-message": 1,
+			"/primary":           1,
+			"/always":            100,
+		}
+
+		api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			requests = append(requests, req)
+			body, _ := io.ReadAll(req.Body)
+			bodies = append(bodies, string(body))
+
+			if limited[req.URL.Path] > 0 {
+				limited[req.URL.Path]--
+
+				switch req.URL.Path {
+				case "/secondary", "/always":
+					w.Header().Set("Retry-After", "1")
+					w.WriteHeader(http.StatusTooManyRequests)
+					fmt.Fprintln(w, `{ "message": "You have exceeded a secondary rate limit." }`)
+
+				case "/secondary-message":
+					w.WriteHeader(http.StatusForbidden)
+					fmt.Fprintln(w, `{ "message": "You have exceeded a secondary rate limit." }`)
+
+				case "/primary":
+					w.Header().Set("X-RateLimit-Remaining", "0")
+					w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Second).Unix(), 10))
+					w.WriteHeader(http.StatusForbidden)
+					fmt.Fprintln(w, `{ "message": "API rate limit exceeded" }`)
+				}
+				return
+			}
+
+			switch req.URL.Path {
+			case "/forbidden":
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprintln(w, `{ "message": "Resource not accessible by integration" }`)
+
+			default:
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintln(w, `{}`)
+			}
+		}))
+
+		buffer = gbytes.NewBuffer()
+		transport = github.NewRateLimitTransport(nil, 5*time.Second, buffer)
+		client = &http.Client{Transport: transport}
+	})
+
+	it.After(func() {
+		api.Close()
+	})
+
+	context("when the request hits a secondary rate limit with a Retry-After header", func() {
+		it("waits and retries the request with its body", func() {
+			resp, err := client.Post(api.URL+"/secondary", "application/json", strings.NewReader(`{"some": "body"}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			Expect(requests).To(HaveLen(2))
+			Expect(bodies).To(Equal([]string{`{"some": "body"}`, `{"some": "body"}`}))
+			Expect(transport.Waited()).To(Equal(time.Second))
+			Expect(buffer).To(gbytes.Say(fmt.Sprintf(`Hit secondary rate limit on POST %s/secondary, waiting 1s`, api.URL)))
+		})
+	})
+
+	context("when the request hits a secondary rate limit without a Retry-After header", func() {
+		it.Before(func() {
+			transport.SecondaryWait = 2 * time.Second
+		})
+
+		it("waits for the configured secondary wait before retrying", func() {
+			resp, err := client.Get(api.URL + "/secondary-message")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			Expect(requests).To(HaveLen(2))
+			Expect(transport.Waited()).To(Equal(2 * time.Second))
+			Expect(buffer).To(gbytes.Say(`Hit secondary rate limit on GET .*, waiting 2s`))
+		})
+	})
+
+	context("when the primary rate limit is exhausted", func() {
+		it("waits until the limit resets", func() {
+			resp, err := client.Get(api.URL + "/primary")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			Expect(requests).To(HaveLen(2))
+			Expect(transport.Waited()).To(BeNumerically(">=", time.Second))
+			Expect(buffer).To(gbytes.Say(`Hit primary rate limit on GET`))
+		})
+	})
+
+	context("when the response is forbidden for some other reason", func() {
+		it("returns the response without waiting", func() {
+			resp, err := client.Get(api.URL + "/forbidden")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusForbidden))
+
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(body)).To(ContainSubstring("Resource not accessible by integration"))
+
+			Expect(requests).To(HaveLen(1))
+			Expect(transport.Waited()).To(BeZero())
+		})
+	})
+
+	context("when waiting would exceed the maximum wait", func() {
+		it.Before(func() {
+			transport.MaxWait = 2 * time.Second
+		})
+
+		it("gives up and returns the rate-limited response", func() {
+			resp, err := client.Get(api.URL + "/always")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusTooManyRequests))
+
+			Expect(requests).To(HaveLen(3))
+			Expect(transport.Waited()).To(Equal(2 * time.Second))
+			Expect(buffer).To(gbytes.Say(`waiting 1s would exceed the maximum wait of 2s`))
+		})
+	})
+
+	context("when used by a Client", func() {
+		it("surfaces the rate-limited response as an error once the wait is exhausted", func() {
+			ghClient := github.NewClient(api.URL, github.WithRateLimitWait(time.Second))
+
+			req, err := ghClient.NewRequest("GET", "/always", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = ghClient.Do(req, nil)
+			Expect(err).To(MatchError(ContainSubstring("429 Too Many Requests: You have exceeded a secondary rate limit.")))
+			Expect(requests).To(HaveLen(2))
+		})
+	})
+}
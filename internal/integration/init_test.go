@@ -0,0 +1,49 @@
+package integration_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	. "github.com/onsi/gomega"
+)
+
+var entrypoints struct {
+	CalculateSemver string
+	CreateRelease   string
+	ResetDraft      string
+}
+
+func TestIntegration(t *testing.T) {
+	var Expect = NewWithT(t).Expect
+
+	SetDefaultEventuallyTimeout(10 * time.Second)
+
+	binDir := t.TempDir()
+
+	// Each entrypoint is its own module, so they are built from their own
+	// directories rather than by import path
+	build := func(dir string) string {
+		output := filepath.Join(binDir, filepath.Base(filepath.Dir(dir)))
+
+		command := exec.Command("go", "build", "-o", output, ".")
+		command.Dir = filepath.Join("..", "..", dir)
+		command.Stderr = os.Stderr
+		Expect(command.Run()).To(Succeed())
+
+		return output
+	}
+
+	entrypoints.CalculateSemver = build("actions/tag/calculate-semver/entrypoint")
+	entrypoints.CreateRelease = build("actions/release/create/entrypoint")
+	entrypoints.ResetDraft = build("actions/release/reset-draft/entrypoint")
+
+	suite := spec.New("integration", spec.Report(report.Terminal{}))
+	suite("ReleaseFlow", testReleaseFlow)
+	suite.Run(t)
+}
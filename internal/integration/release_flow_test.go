@@ -0,0 +1,116 @@
+package integration_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/paketo-buildpacks/github-config/internal/github/githubtest"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testReleaseFlow(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect     = NewWithT(t).Expect
+		Eventually = NewWithT(t).Eventually
+
+		server  *githubtest.Server
+		repo    *githubtest.Repo
+		tempDir string
+	)
+
+	it.Before(func() {
+		server = githubtest.NewServer()
+		server.RequireToken("some-github-token")
+
+		repo = server.AddRepo("some-org/some-repo").
+			WithCommit("sha-1", "Initial commit").
+			WithRelease(githubtest.Release{TagName: "v1.0.0", Name: "v1.0.0"}).
+			WithMergedPullRequest("sha-2", "Fixes a bug", "semver:patch").
+			WithMergedPullRequest("sha-3", "Adds a feature", "semver:minor")
+
+		tempDir = t.TempDir()
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	// run executes the entrypoint against the fake and returns the outputs it
+	// wrote to GITHUB_OUTPUT
+	run := func(entrypoint string, args ...string) string {
+		outputFile := filepath.Join(tempDir, "github-output")
+		Expect(os.WriteFile(outputFile, nil, 0600)).To(Succeed())
+
+		command := exec.Command(entrypoint, append([]string{
+			"--endpoint", server.URL,
+			"--repo", "some-org/some-repo",
+			"--token", "some-github-token",
+		}, args...)...)
+		command.Env = append(os.Environ(), fmt.Sprintf("GITHUB_OUTPUT=%s", outputFile))
+
+		buffer := gbytes.NewBuffer()
+		session, err := gexec.Start(command, buffer, buffer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(session).Should(gexec.Exit(0), func() string { return fmt.Sprintf("output:\n%s\n", buffer.Contents()) })
+
+		output, err := os.ReadFile(outputFile)
+		Expect(err).NotTo(HaveOccurred())
+
+		return string(output)
+	}
+
+	it("drafts, resets and publishes releases against one repository", func() {
+		Expect(run(entrypoints.CalculateSemver, "--ref-name", "main")).To(HavePrefix("tag=1.1.0\n"))
+
+		run(entrypoints.CreateRelease,
+			"--tag-name", "v1.1.0",
+			"--target-commitish", "main",
+			"--name", "v1.1.0",
+			"--draft",
+		)
+
+		draft, ok := repo.Release("v1.1.0")
+		Expect(ok).To(BeTrue())
+		Expect(draft.Draft).To(BeTrue())
+
+		// Drafts are not considered when calculating the next version
+		Expect(run(entrypoints.CalculateSemver, "--ref-name", "main")).To(HavePrefix("tag=1.1.0\n"))
+
+		Expect(run(entrypoints.ResetDraft)).To(Equal("current_version=v1.1.0\n"))
+
+		_, ok = repo.Release("v1.1.0")
+		Expect(ok).To(BeFalse())
+
+		assetPath := filepath.Join(tempDir, "some-asset.tgz")
+		Expect(os.WriteFile(assetPath, []byte("some-content"), 0600)).To(Succeed())
+
+		run(entrypoints.CreateRelease,
+			"--tag-name", "v1.1.0",
+			"--target-commitish", "main",
+			"--name", "v1.1.0",
+			"--assets", fmt.Sprintf(`[{"path": %q, "name": "some-asset.tgz", "content_type": "application/gzip"}]`, assetPath),
+		)
+
+		release, ok := repo.Release("v1.1.0")
+		Expect(ok).To(BeTrue())
+		Expect(release.Draft).To(BeFalse())
+		Expect(release.Assets).To(HaveLen(1))
+		Expect(string(release.Assets[0].Content)).To(Equal("some-content"))
+
+		// With nothing to reset, the action leaves the published release alone
+		Expect(run(entrypoints.ResetDraft)).To(BeEmpty())
+		Expect(repo.Releases()).To(HaveLen(2))
+
+		repo.WithMergedPullRequest("sha-4", "Fixes another bug", "semver:patch")
+
+		Expect(run(entrypoints.CalculateSemver, "--ref-name", "main")).To(HavePrefix("tag=1.1.1\n"))
+	})
+}
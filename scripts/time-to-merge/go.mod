@@ -5,6 +5,7 @@ go 1.26.1
 require (
 	github.com/aclements/go-moremath v0.0.0-20241023150245-c8bbc672ef66
 	github.com/onsi/gomega v1.39.1
+	github.com/paketo-buildpacks/github-config/internal/github v0.0.0
 	github.com/paketo-buildpacks/occam v0.31.2
 	github.com/sclevine/spec v1.4.0
 )
@@ -81,3 +82,5 @@ require (
 	google.golang.org/grpc v1.71.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/paketo-buildpacks/github-config/internal/github => ../../internal/github
@@ -1,16 +1,14 @@
 package internal
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
 	"math"
-	"net/http"
 	"net/url"
-	"os"
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/paketo-buildpacks/github-config/internal/github"
 )
 
 const dateLayout string = "2006-01-02T15:04:05Z"
@@ -47,37 +45,23 @@ type Commit struct {
 	} `json:"commit"`
 }
 
-func getPullRequestCommits(pullRequest PullRequest, serverURI string) ([]Commit, error) {
+func getPullRequestCommits(client *github.Client, pullRequest PullRequest) ([]Commit, error) {
 	commitsURL, err := url.Parse(pullRequest.Links.Commits.CommitsURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse commits URL: %s", err)
 	}
 
-	client := &http.Client{}
-	uri, err := url.Parse(serverURI)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse server URL: %s", err)
-	}
-
-	uri.Path = commitsURL.Path
-
-	request, err := http.NewRequest("GET", uri.String(), nil)
+	// The commits link points at the public API host, so only its path is
+	// requested from the configured server
+	request, err := client.NewRequest("GET", commitsURL.Path, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create http GET request for commits: %s", err)
 	}
-	request.Header.Add("Authorization", fmt.Sprintf("token %s", os.Getenv("PAKETO_GITHUB_TOKEN")))
 
-	response, err := client.Do(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make http GET request for commits: %s", err)
-	}
-
-	body, _ := io.ReadAll(response.Body)
 	pullRequestCommits := []Commit{}
-
-	err = json.Unmarshal(body, &pullRequestCommits)
+	_, err = client.Do(request, &pullRequestCommits)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal %s\n from API endpoint %s: %s", string(body), uri.String(), err)
+		return nil, fmt.Errorf("failed to get commits from API endpoint %s: %s", request.URL, err)
 	}
 	return pullRequestCommits, nil
 }
@@ -101,12 +85,12 @@ func GetLastCommit(commits []Commit) (Commit, error) {
 	return Commit{}, fmt.Errorf("PR has no last commit")
 }
 
-func calculateMinutesToMerge(pullRequest PullRequest, serverURI string) (float64, error) {
+func calculateMinutesToMerge(client *github.Client, pullRequest PullRequest) (float64, error) {
 	if pullRequest.MergedAt == "" {
 		return -1, fmt.Errorf("this pull request was never merged")
 	}
 
-	pullRequestCommits, err := getPullRequestCommits(pullRequest, serverURI)
+	pullRequestCommits, err := getPullRequestCommits(client, pullRequest)
 	if err != nil {
 		return -1, fmt.Errorf("could not get commits from closed pull request: %s", err)
 	}
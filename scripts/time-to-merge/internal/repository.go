@@ -1,14 +1,11 @@
 package internal
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"os"
 	"strings"
 	"time"
+
+	"github.com/paketo-buildpacks/github-config/internal/github"
 )
 
 type RepositoryContainer struct {
@@ -24,39 +21,16 @@ type Repository struct {
 	} `json:"owner"`
 }
 
-func GetOrgRepos(org string, serverURI string) ([]Repository, error) {
-	client := &http.Client{}
-	uri, err := url.Parse(serverURI)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse server URL: %s", err)
-	}
-
-	uri.Path = fmt.Sprintf("/orgs/%s/repos", org)
-	uri.RawQuery = "per_page=100"
-
-	request, err := http.NewRequest("GET", uri.String(), nil)
+func GetOrgRepos(client *github.Client, org string) ([]Repository, error) {
+	repos, err := github.GetAll[Repository](client, fmt.Sprintf("/orgs/%s/repos?per_page=100", org))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create http GET request for repositories: %s", err)
-	}
-
-	request.Header.Add("Authorization", fmt.Sprintf("token %s", os.Getenv("PAKETO_GITHUB_TOKEN")))
-
-	response, err := client.Do(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make http request for repositories: %s", err)
-	}
-	body, _ := io.ReadAll(response.Body)
-
-	repos := []Repository{}
-	err = json.Unmarshal(body, &repos)
-	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal response: %s\n from API endpoint: %s : %s", string(body), uri.String(), err)
+		return nil, fmt.Errorf("failed to list repositories for %s: %s", org, err)
 	}
 	return repos, nil
 }
 
-func GetRepoMergeTimes(repo Repository, serverURI string, output chan MergeTimeContainer) {
-	pullRequests, err := getClosedPullRequests(repo, serverURI)
+func GetRepoMergeTimes(client *github.Client, repo Repository, output chan MergeTimeContainer) {
+	pullRequests, err := getClosedPullRequests(client, repo)
 	if err != nil {
 		output <- MergeTimeContainer{Error: fmt.Errorf("failed to get closed pull requests: %s", err)}
 		return
@@ -82,7 +56,7 @@ func GetRepoMergeTimes(repo Repository, serverURI string, output chan MergeTimeC
 		if strings.Contains(pullRequest.Title, "rfc") || strings.Contains(pullRequest.Title, "RFC") {
 			continue
 		}
-		mergeTime, err := calculateMinutesToMerge(pullRequest, serverURI)
+		mergeTime, err := calculateMinutesToMerge(client, pullRequest)
 		if err != nil {
 			output <- MergeTimeContainer{Error: fmt.Errorf("failed to compute merge time for a pull request: %s", err)}
 			return
@@ -92,32 +66,16 @@ func GetRepoMergeTimes(repo Repository, serverURI string, output chan MergeTimeC
 	}
 }
 
-func getClosedPullRequests(repo Repository, serverURI string) ([]PullRequest, error) {
-	client := &http.Client{}
-	uri, err := url.Parse(serverURI)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse server URL: %s", err)
-	}
-	uri.Path = fmt.Sprintf("/repos/%s/%s/pulls", repo.Owner.Login, repo.Name)
-	uri.RawQuery = "per_page=200&state=closed"
-	request, err := http.NewRequest("GET", uri.String(), nil)
+func getClosedPullRequests(client *github.Client, repo Repository) ([]PullRequest, error) {
+	request, err := client.NewRequest("GET", fmt.Sprintf("/repos/%s/%s/pulls?per_page=200&state=closed", repo.Owner.Login, repo.Name), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create http GET request for closed PRs: %s", err)
 	}
 
-	request.Header.Add("Authorization", fmt.Sprintf("token %s", os.Getenv("PAKETO_GITHUB_TOKEN")))
-
-	response, err := client.Do(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make http request for closed PRs: %s", err)
-	}
-
-	body, _ := io.ReadAll(response.Body)
 	pullRequests := []PullRequest{}
-	err = json.Unmarshal(body, &pullRequests)
-
+	_, err = client.Do(request, &pullRequests)
 	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal response: %s\n from API endpoint: %s : %s", string(body), uri.String(), err)
+		return nil, fmt.Errorf("failed to get closed PRs from API endpoint %s: %s", request.URL, err)
 	}
 
 	return pullRequests, nil
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/aclements/go-moremath/stats"
+	"github.com/paketo-buildpacks/github-config/internal/github"
 	"github.com/paketo-buildpacks/github-config/scripts/time-to-merge/internal"
 )
 
@@ -19,21 +20,36 @@ func main() {
 	var numWorkers int
 	start := time.Now()
 
-	flag.StringVar(&githubServer, "server", "https://api.github.com", "base URL for the github API")
+	flag.StringVar(&githubServer, "server", github.DefaultEndpoint, "base URL for the github API")
 	flag.IntVar(&numWorkers, "workers", 1, "number of concurrent workers to use")
+	clientFlags := github.RegisterClientFlags(flag.CommandLine, github.ClientEnv{
+		Token:          "PAKETO_GITHUB_TOKEN",
+		AppID:          "PAKETO_GITHUB_APP_ID",
+		InstallationID: "PAKETO_GITHUB_APP_INSTALLATION_ID",
+		PrivateKey:     "PAKETO_GITHUB_APP_PRIVATE_KEY",
+	}, 30*time.Minute)
 	flag.Parse()
 
-	if os.Getenv("PAKETO_GITHUB_TOKEN") == "" {
+	if !clientFlags.HasCredentials() {
 		fmt.Println("Please set PAKETO_GITHUB_TOKEN")
+		fmt.Println("or PAKETO_GITHUB_APP_ID, PAKETO_GITHUB_APP_INSTALLATION_ID and PAKETO_GITHUB_APP_PRIVATE_KEY")
 		os.Exit(1)
 	}
 
-	in := getOrgReposChan(orgs, githubServer)
+	// Installation tokens expire after an hour and are refreshed by the client
+	// as the run goes on
+	client, err := clientFlags.NewClient(githubServer)
+	if err != nil {
+		fmt.Printf("invalid GitHub App credentials: %s\n", err)
+		os.Exit(1)
+	}
+
+	in := getOrgReposChan(client, orgs)
 
 	fmt.Printf("Running with %d workers...\nUse --workers to set.\n\n", numWorkers)
 	var workers []<-chan internal.MergeTimeContainer
 	for i := 0; i < numWorkers; i++ {
-		workers = append(workers, worker(i, githubServer, in))
+		workers = append(workers, worker(i, client, in))
 	}
 
 	for timeContainer := range merge(workers...) {
@@ -54,7 +70,7 @@ func main() {
 	fmt.Printf("Execution took %f seconds.\n", duration.Seconds())
 }
 
-func worker(id int, serverURI string, input <-chan internal.RepositoryContainer) chan internal.MergeTimeContainer {
+func worker(id int, client *github.Client, input <-chan internal.RepositoryContainer) chan internal.MergeTimeContainer {
 	output := make(chan internal.MergeTimeContainer)
 
 	go func() {
@@ -64,7 +80,7 @@ func worker(id int, serverURI string, input <-chan internal.RepositoryContainer)
 				close(output)
 				return
 			}
-			internal.GetRepoMergeTimes(repoContainer.Repository, serverURI, output)
+			internal.GetRepoMergeTimes(client, repoContainer.Repository, output)
 		}
 		close(output)
 	}()
@@ -92,11 +108,11 @@ func merge(ws ...<-chan internal.MergeTimeContainer) chan internal.MergeTimeCont
 	return output
 }
 
-func getOrgReposChan(orgs []string, serverURI string) chan internal.RepositoryContainer {
+func getOrgReposChan(client *github.Client, orgs []string) chan internal.RepositoryContainer {
 	output := make(chan internal.RepositoryContainer)
 	go func() {
 		for _, org := range orgs {
-			repos, err := internal.GetOrgRepos(org, serverURI)
+			repos, err := internal.GetOrgRepos(client, org)
 			if err != nil {
 				output <- internal.RepositoryContainer{Error: fmt.Errorf("failed to get repositories: %s", err)}
 			}
@@ -43,6 +43,12 @@ function main() {
       go test -v -count=1 .
     popd > /dev/null || return
   done < <(find "${ROOT_DIR}" -type d -name entrypoint ! -path '*/.git/*')
+
+  while read -r directory; do
+    pushd "${directory}" > /dev/null || return
+      go test -v -count=1 ./...
+    popd > /dev/null || return
+  done < <(find "${ROOT_DIR}/internal" -name go.mod -exec dirname {} \;)
 }
 
 function usage() {